@@ -2,15 +2,21 @@
 // @version      1.0
 // @description  API Gateway для микросервисов
 // @host         localhost:8080
-// @BasePath     /api
+// @BasePath     /api/v1
 package main
 
 import (
+	"context"
+	"crypto/rand"
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/joho/godotenv"
+	"github.com/redis/go-redis/v9"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 
@@ -18,11 +24,19 @@ import (
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/apiversion"
 	"github.com/Ostap00034/course-work-backend-api-gateway/internal/auth"
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/cache"
 	"github.com/Ostap00034/course-work-backend-api-gateway/internal/category"
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/coalesce"
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/graphql"
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/idempotency"
 	"github.com/Ostap00034/course-work-backend-api-gateway/internal/offer"
 	"github.com/Ostap00034/course-work-backend-api-gateway/internal/order"
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/storage"
 	"github.com/Ostap00034/course-work-backend-api-gateway/internal/user"
+	categorypbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/category/v1"
+	orderpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/order/v1"
 )
 
 func init() {
@@ -35,8 +49,6 @@ func init() {
 func main() {
 	// 1) Gin + middleware
 	r := gin.Default()
-	api := r.Group("api")
-	api.Use(auth.Middleware())
 
 	// 2) gRPC–сonnections
 	authSvcAddr, exists := os.LookupEnv("AUTH_SERVICE_ADDR")
@@ -61,7 +73,13 @@ func main() {
 	if !exists {
 		log.Fatal("not CATEGORY_SERVICE_ADDR in .env file")
 	}
-	categoryConn, err := grpc.NewClient(categorySvcAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	categoryConn, err := grpc.NewClient(categorySvcAddr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(coalesce.UnaryClientInterceptor(
+			categorypbv1.CategoryService_GetCategories_FullMethodName,
+			categorypbv1.CategoryService_GetCategoryById_FullMethodName,
+		)),
+	)
 	if err != nil {
 		log.Fatalf("failed to dial category-service: %v", err)
 	}
@@ -70,7 +88,12 @@ func main() {
 	if !exists {
 		log.Fatal("not ORDER_SERVICE_ADDR in .env file")
 	}
-	orderConn, err := grpc.NewClient(orderSvcAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	orderConn, err := grpc.NewClient(orderSvcAddr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(coalesce.UnaryClientInterceptor(
+			orderpbv1.OrderService_GetOrderById_FullMethodName,
+		)),
+	)
 	if err != nil {
 		log.Fatalf("failed to dial order-service: %v", err)
 	}
@@ -91,18 +114,166 @@ func main() {
 	orderClient := order.NewClient(orderConn)
 	offerClient := offer.NewClient(offerConn)
 
-	// 4) Роуты по фичам
-	auth.RegisterHandlers(api.Group("/auth"), authClient)
-	user.RegisterHandlers(api.Group("/users"), userClient)
-	category.RegisterHandlers(api.Group("/categories"), categoryClient)
-	order.RegisterHandlers(api.Group("/orders"), orderClient)
+	// Ключи идемпотентности для создания заказов и офферов
+	idemTTL := 24 * time.Hour
+	if raw, exists := os.LookupEnv("IDEMPOTENCY_TTL"); exists {
+		idemTTL, err = time.ParseDuration(raw)
+		if err != nil {
+			log.Fatalf("invalid IDEMPOTENCY_TTL: %v", err)
+		}
+	}
+	idemStore := idempotency.NewStore(idemTTL)
 
-	// 5) Swagger UI
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	// Иерархия категорий хранится на стороне шлюза: с CATEGORY_PARENTS_URL —
+	// в Redis, общем для всех реплик, иначе в памяти и файле одной реплики.
+	var categoryParents *category.ParentStore
+	if parentsURL, exists := os.LookupEnv("CATEGORY_PARENTS_URL"); exists {
+		opts, err := redis.ParseURL(parentsURL)
+		if err != nil {
+			log.Fatalf("invalid CATEGORY_PARENTS_URL: %v", err)
+		}
+		key := "category-parents"
+		if raw, exists := os.LookupEnv("CATEGORY_PARENTS_KEY"); exists {
+			key = raw
+		}
+		categoryParents = category.NewRedisParentStore(redis.NewClient(opts), key)
+	} else {
+		categoryParents, err = category.NewParentStore(os.Getenv("CATEGORY_PARENTS_FILE"))
+		if err != nil {
+			log.Fatalf("failed to load category hierarchy: %v", err)
+		}
+	}
+
+	// Кэш ответов сервиса категорий
+	categoryCacheOpts := cache.Options{TTL: time.Minute, Stale: 5 * time.Minute, MaxSize: 1000}
+	if raw, exists := os.LookupEnv("CATEGORY_CACHE_TTL"); exists {
+		categoryCacheOpts.TTL, err = time.ParseDuration(raw)
+		if err != nil {
+			log.Fatalf("invalid CATEGORY_CACHE_TTL: %v", err)
+		}
+	}
+	if raw, exists := os.LookupEnv("CATEGORY_CACHE_STALE"); exists {
+		categoryCacheOpts.Stale, err = time.ParseDuration(raw)
+		if err != nil {
+			log.Fatalf("invalid CATEGORY_CACHE_STALE: %v", err)
+		}
+	}
+	if raw, exists := os.LookupEnv("CATEGORY_CACHE_SIZE"); exists {
+		categoryCacheOpts.MaxSize, err = strconv.Atoi(raw)
+		if err != nil {
+			log.Fatalf("invalid CATEGORY_CACHE_SIZE: %v", err)
+		}
+	}
+	categoryCache := category.NewReadCache(categoryCacheOpts)
+
+	// Хранилище картинок и подпись ссылок на них
+	mediaDir, exists := os.LookupEnv("MEDIA_DIR")
+	if !exists {
+		mediaDir = "data/media"
+	}
+	mediaStore, err := storage.NewLocal(mediaDir)
+	if err != nil {
+		log.Fatalf("failed to init media storage: %v", err)
+	}
+	mediaKey, exists := os.LookupEnv("MEDIA_SIGNING_KEY")
+	if !exists {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			log.Fatalf("failed to generate media signing key: %v", err)
+		}
+		mediaKey = string(b)
+		log.Print("no MEDIA_SIGNING_KEY in .env file, media links will expire on restart")
+	}
+	mediaSigner := storage.NewSigner([]byte(mediaKey), "/api/v1/media", time.Hour)
 
-	// WebSocket для offer
+	// WebSocket для offer: один hub на все версии API. С OFFER_BROKER_URL
+	// события расходятся между репликами шлюза через Redis Pub/Sub.
 	hub := offer.NewHub()
-	api.GET("/ws/offers", offer.OfferWsHandler(hub, offerClient, authClient))
+	if brokerURL, exists := os.LookupEnv("OFFER_BROKER_URL"); exists {
+		opts, err := redis.ParseURL(brokerURL)
+		if err != nil {
+			log.Fatalf("invalid OFFER_BROKER_URL: %v", err)
+		}
+		channel := "offer-events"
+		if raw, exists := os.LookupEnv("OFFER_BROKER_CHANNEL"); exists {
+			channel = raw
+		}
+		instanceID, exists := os.LookupEnv("INSTANCE_ID")
+		if !exists {
+			host, _ := os.Hostname()
+			instanceID = host + "-" + uuid.NewString()
+		}
+		hub = offer.NewClusterHub(instanceID, offer.NewRedisBroker(redis.NewClient(opts), channel))
+		go hub.Run(context.Background())
+	}
+	// изменения офферов в обход шлюза подхватываются опросом OfferService
+	// (потокового RPC в нём нет) от имени сервиса с токеном
+	// OFFER_POLLER_TOKEN — JWT с ограниченным сроком, которым шлюз только
+	// читает офферы; без токена или с OFFER_POLL_INTERVAL=0 опрос отключён
+	offerPollInterval := 5 * time.Second
+	if raw, exists := os.LookupEnv("OFFER_POLL_INTERVAL"); exists {
+		offerPollInterval, err = time.ParseDuration(raw)
+		if err != nil {
+			log.Fatalf("invalid OFFER_POLL_INTERVAL: %v", err)
+		}
+	}
+	offerPollerToken, exists := os.LookupEnv("OFFER_POLLER_TOKEN")
+	switch {
+	case offerPollInterval <= 0:
+	case !exists || offerPollerToken == "":
+		log.Printf("OFFER_POLLER_TOKEN is not set, offer polling disabled")
+	default:
+		poller, err := offer.NewPoller(hub, offerClient, offerPollInterval, offerPollerToken)
+		if err != nil {
+			log.Fatalf("invalid OFFER_POLLER_TOKEN: %v", err)
+		}
+		go poller.Run(context.Background())
+	}
+
+	// GraphQL поверх тех же gRPC-клиентов; подписки получают события из hub
+	gqlClients := graphql.Clients{
+		Users:      userClient,
+		Orders:     orderClient,
+		Categories: categoryClient,
+		Offers:     offerClient,
+	}
+	gqlSchema, err := graphql.NewSchema(gqlClients, hub)
+	if err != nil {
+		log.Fatalf("failed to build GraphQL schema: %v", err)
+	}
+
+	// 4) Роуты всех версий API; у маршрутов без версии есть дата отключения
+	legacy := apiversion.Deprecation{Successor: apiversion.ReplacePrefix("/api", "/api/v1")}
+	if raw, exists := os.LookupEnv("LEGACY_API_DEPRECATED_AT"); exists {
+		legacy.At, err = time.Parse(time.DateOnly, raw)
+		if err != nil {
+			log.Fatalf("invalid LEGACY_API_DEPRECATED_AT: %v", err)
+		}
+	}
+	if raw, exists := os.LookupEnv("LEGACY_API_SUNSET"); exists {
+		legacy.Sunset, err = time.Parse(time.DateOnly, raw)
+		if err != nil {
+			log.Fatalf("invalid LEGACY_API_SUNSET: %v", err)
+		}
+	}
+	registerAPI(r, services{
+		authClient:      authClient,
+		userClient:      userClient,
+		categoryClient:  categoryClient,
+		orderClient:     orderClient,
+		offerClient:     offerClient,
+		idemStore:       idemStore,
+		categoryParents: categoryParents,
+		categoryCache:   categoryCache,
+		mediaStore:      mediaStore,
+		mediaSigner:     mediaSigner,
+		hub:             hub,
+		gqlSchema:       gqlSchema,
+		gqlClients:      gqlClients,
+	}, legacy)
+
+	// 5) Swagger UI
+	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// 6) Запуск
 	addr, exists := os.LookupEnv("GATEWAY_ADDR")
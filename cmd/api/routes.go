@@ -0,0 +1,74 @@
+package main
+
+import (
+	"expvar"
+
+	"github.com/gin-gonic/gin"
+	graphqlgo "github.com/graph-gophers/graphql-go"
+
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/apiversion"
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/auth"
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/category"
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/graphql"
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/idempotency"
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/media"
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/offer"
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/order"
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/storage"
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/user"
+	authpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/auth/v1"
+	categorypbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/category/v1"
+	offerpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/offer/v1"
+	orderpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/order/v1"
+	userv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/user/v1"
+)
+
+// services — всё, что нужно обработчикам API.
+type services struct {
+	authClient     authpbv1.AuthServiceClient
+	userClient     userv1.UserServiceClient
+	categoryClient categorypbv1.CategoryServiceClient
+	orderClient    orderpbv1.OrderServiceClient
+	offerClient    offerpbv1.OfferServiceClient
+
+	idemStore       *idempotency.Store
+	categoryParents *category.ParentStore
+	categoryCache   *category.ReadCache
+	mediaStore      storage.Storage
+	mediaSigner     *storage.Signer
+	hub             *offer.Hub
+	gqlSchema       *graphqlgo.Schema
+	gqlClients      graphql.Clients
+}
+
+// registerAPI вешает все версии API: /api/v1, /api/v2 и устаревшие
+// маршруты без версии /api/..., которые помечаются по legacy.
+func registerAPI(r *gin.Engine, s services, legacy apiversion.Deprecation) {
+	api := r.Group("api")
+	api.Use(auth.Middleware())
+
+	registerRoutes(apiversion.Group(api, apiversion.V1), s)
+	// v2 отличается форматом ответа: {data, error} вместо {success, message, errors}
+	registerRoutes(apiversion.Group(api, apiversion.V2, apiversion.Adapt(apiversion.EnvelopeV2)), s)
+	// Маршруты без версии (/api/...) оставлены для старых клиентов и будут удалены
+	registerRoutes(r.Group("api", auth.Middleware(), apiversion.Middleware(apiversion.V1), apiversion.Deprecated(legacy)), s)
+}
+
+// registerRoutes вешает роуты по фичам; одни и те же обработчики
+// обслуживают все версии API.
+func registerRoutes(api *gin.RouterGroup, s services) {
+	auth.RegisterHandlers(api.Group("/auth"), s.authClient)
+	user.RegisterHandlers(api.Group("/users"), s.userClient)
+	category.RegisterHandlers(api.Group("/categories"), s.categoryClient, s.orderClient, s.categoryParents, s.categoryCache)
+	order.RegisterHandlers(api.Group("/orders"), s.orderClient, s.userClient, s.categoryClient, s.idemStore)
+	order.RegisterMeHandlers(api.Group("/me"), s.orderClient)
+	media.RegisterHandlers(api, s.mediaStore, s.mediaSigner, s.categoryClient, s.orderClient)
+	offer.RegisterHandlers(api, s.hub, s.offerClient, s.orderClient, s.idemStore)
+
+	// Счётчики процесса, в том числе склеенных gRPC-вызовов
+	api.GET("/debug/vars", auth.AdminOnly(), gin.WrapH(expvar.Handler()))
+
+	api.GET("/ws/offers", offer.OfferWsHandler(s.hub, s.offerClient, s.authClient, s.orderClient, s.idemStore))
+	api.GET("/ws/offers/asyncapi.json", offer.AsyncAPIHandler())
+	graphql.RegisterHandlers(api, s.gqlSchema, s.gqlClients)
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/apiversion"
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/cache"
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/category"
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/graphql"
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/idempotency"
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/offer"
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/storage"
+)
+
+// route — ожидаемый маршрут внутри версии API: путь без префикса версии,
+// обработчик и middleware маршрута в виде "пакет.Функция".
+type route struct {
+	method, path, handler string
+	middleware            []string
+}
+
+var (
+	adminOnly  = []string{"auth.AdminOnly"}
+	idempotent = []string{"idempotency.Middleware"}
+)
+
+// apiRoutes — маршруты каждой версии API.
+var apiRoutes = []route{
+	{"POST", "/auth/login", "auth.LoginHandler", nil},
+	{"GET", "/auth/validate", "auth.ValidateHandler", nil},
+	{"POST", "/auth/logout", "auth.LogoutHandler", nil},
+
+	{"GET", "/users/", "user.GetUsersHandler", nil},
+	{"POST", "/users/create", "user.CreateUserHandler", nil},
+	{"GET", "/users/profile/:id", "user.GetProfileHandler", nil},
+	{"PUT", "/users/profile/:id", "user.ReplaceProfileHandler", nil},
+	{"PATCH", "/users/profile/:id", "user.ChangeProfileHandler", nil},
+
+	{"POST", "/categories/", "category.CreateCategoryHandler", nil},
+	{"GET", "/categories/", "category.GetCategoriesHandler", nil},
+	{"GET", "/categories/tree", "category.GetCategoryTreeHandler", nil},
+	{"GET", "/categories/:id", "category.GetCategoryHandler", nil},
+	{"PUT", "/categories/:id", "category.UpdateCategoryHandler", nil},
+	{"PATCH", "/categories/:id", "category.PatchCategoryHandler", nil},
+	{"DELETE", "/categories/:id", "category.DeleteCategoryHandler", adminOnly},
+	{"GET", "/categories/:id/icon", "media.GetCategoryIconHandler", nil},
+	{"POST", "/categories/:id/icon", "media.UploadCategoryIconHandler", adminOnly},
+
+	{"POST", "/orders/", "order.CreateOrderHandler", idempotent},
+	{"GET", "/orders/", "order.GetOrdersHandler", nil},
+	{"GET", "/orders/nearby", "order.GetNearbyOrdersHandler", nil},
+	{"GET", "/orders/:id", "order.GetOrderHandler", nil},
+	{"PUT", "/orders/:id", "order.UpdateOrderHandler", nil},
+	{"PATCH", "/orders/:id", "order.PatchOrderHandler", nil},
+	{"DELETE", "/orders/:id", "order.DeleteOrderHandler", nil},
+	{"POST", "/orders/:id/assign", "order.AssignOrderHandler", nil},
+	{"POST", "/orders/:id/start", "order.changeStatusHandler", nil},
+	{"POST", "/orders/:id/complete", "order.changeStatusHandler", nil},
+	{"POST", "/orders/:id/cancel", "order.changeStatusHandler", nil},
+	{"GET", "/orders/:id/photos", "media.GetOrderPhotosHandler", nil},
+	{"POST", "/orders/:id/photos", "media.UploadOrderPhotosHandler", nil},
+	{"GET", "/orders/:id/offers", "offer.GetOrderOffersHandler", nil},
+	{"GET", "/orders/:id/offers/stream", "offer.StreamOffersHandler", nil},
+	{"GET", "/me/orders", "order.GetMyOrdersHandler", nil},
+	{"GET", "/me/orders/finished", "order.GetMyFinishedOrdersHandler", nil},
+
+	{"GET", "/media/*key", "media.ServeHandler", nil},
+
+	{"POST", "/offers", "offer.CreateOfferHandler", idempotent},
+	{"GET", "/offers/:id", "offer.GetOfferHandler", nil},
+	{"PATCH", "/offers/:id", "offer.UpdateOfferHandler", nil},
+	{"POST", "/offers/:id/accept", "offer.AcceptOfferHandler", nil},
+
+	{"GET", "/debug/vars", "gin.WrapH", adminOnly},
+	{"GET", "/ws/offers", "offer.OfferWsHandler", nil},
+	{"GET", "/ws/offers/asyncapi.json", "offer.AsyncAPIHandler", nil},
+	{"POST", "/graphql", "graphql.QueryHandler", nil},
+	{"GET", "/graphql", "graphql.SubscriptionHandler", nil},
+}
+
+// apiPrefixes — версии API: каждая обслуживает все apiRoutes.
+var apiPrefixes = []string{"/api/v1", "/api/v2", "/api"}
+
+// prefixMiddleware — middleware, которые версия API ставит перед
+// middleware маршрута.
+var prefixMiddleware = map[string][]string{
+	"/api/v1": {"util.CookieToMetadata", "apiversion.Middleware"},
+	"/api/v2": {"util.CookieToMetadata", "apiversion.Middleware", "apiversion.Adapt"},
+	"/api":    {"util.CookieToMetadata", "apiversion.Middleware", "apiversion.Deprecated"},
+}
+
+// reservedSegments — статические сегменты рядом с :id. Они не бывают UUID,
+// поэтому маршрут с :id их и так не получил бы. Новое слово рядом с
+// параметром добавляется сюда осознанно, иначе лучше отдельный префикс,
+// как /me/orders.
+var reservedSegments = map[string]bool{
+	"/categories/tree": true,
+	"/orders/nearby":   true,
+}
+
+// newTestRouter собирает роутер так же, как main, с пустыми клиентами
+// сервисов: обработчики только регистрируются и не вызываются. Глобальные
+// middleware из use встают в начало цепочки каждого маршрута.
+func newTestRouter(t *testing.T, use ...gin.HandlerFunc) (r *gin.Engine) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	hub := offer.NewHub()
+	parents, err := category.NewParentStore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	media, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema, err := graphql.NewSchema(graphql.Clients{}, hub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// gin паникует при повторной регистрации и конфликте маршрутов
+	defer func() {
+		if p := recover(); p != nil {
+			t.Fatalf("route registration: %v", p)
+		}
+	}()
+	r = gin.New()
+	r.Use(use...)
+	registerAPI(r, services{
+		idemStore:       idempotency.NewStore(time.Minute),
+		categoryParents: parents,
+		categoryCache:   category.NewReadCache(cache.Options{}),
+		mediaStore:      media,
+		mediaSigner:     storage.NewSigner([]byte("test"), "/api/v1/media", time.Hour),
+		hub:             hub,
+		gqlSchema:       schema,
+	}, apiversion.Deprecation{})
+	return r
+}
+
+// handlerName сокращает имя обработчика из gin.RouteInfo до "пакет.Функция".
+func handlerName(full string) string {
+	return path.Base(strings.TrimSuffix(full, ".func1"))
+}
+
+// concretePath подставляет значения вместо параметров пути.
+func concretePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, s := range segments {
+		switch {
+		case isParam(s):
+			segments[i] = "00000000-0000-0000-0000-000000000001"
+		case strings.HasPrefix(s, "*"):
+			segments[i] = "x"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func TestRouteTable(t *testing.T) {
+	r := newTestRouter(t)
+
+	want := map[string]string{}
+	for _, prefix := range apiPrefixes {
+		for _, rt := range apiRoutes {
+			key := rt.method + " " + prefix + rt.path
+			if _, dup := want[key]; dup {
+				t.Fatalf("route %s listed twice", key)
+			}
+			want[key] = rt.handler
+		}
+	}
+
+	got := map[string]bool{}
+	for _, ri := range r.Routes() {
+		key := ri.Method + " " + ri.Path
+		if got[key] {
+			t.Errorf("route %s registered twice", key)
+		}
+		got[key] = true
+		handler, ok := want[key]
+		switch {
+		case !ok:
+			t.Errorf("unexpected route %s → %s", key, handlerName(ri.Handler))
+		case handlerName(ri.Handler) != handler:
+			t.Errorf("route %s → %s, want %s", key, handlerName(ri.Handler), handler)
+		}
+	}
+	for key := range want {
+		if !got[key] {
+			t.Errorf("route %s is not registered", key)
+		}
+	}
+}
+
+// TestRouteMiddleware проверяет всю цепочку каждого маршрута: RouteInfo
+// знает только последний обработчик. Первым в цепочке стоит probe, он
+// запоминает имена остальных и прерывает запрос.
+func TestRouteMiddleware(t *testing.T) {
+	var fullPath string
+	var chain []string
+	probe := func(c *gin.Context) {
+		fullPath = c.FullPath()
+		chain = chain[:0]
+		for _, name := range c.HandlerNames()[1:] {
+			chain = append(chain, handlerName(name))
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+	r := newTestRouter(t, probe)
+
+	for _, prefix := range apiPrefixes {
+		for _, rt := range apiRoutes {
+			p := prefix + rt.path
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(rt.method, concretePath(p), nil))
+			if fullPath != p {
+				t.Errorf("%s %s matched %q", rt.method, p, fullPath)
+				continue
+			}
+			want := slices.Concat(prefixMiddleware[prefix], rt.middleware, []string{rt.handler})
+			if !slices.Equal(chain, want) {
+				t.Errorf("%s %s: chain %v, want %v", rt.method, p, chain, want)
+			}
+		}
+	}
+}
+
+func TestNoShadowedRoutes(t *testing.T) {
+	routes := newTestRouter(t).Routes()
+	for i, a := range routes {
+		for _, b := range routes[i+1:] {
+			if a.Method != b.Method {
+				continue
+			}
+			if msg := shadowed(a.Path, b.Path); msg != "" {
+				t.Errorf("%s: %s", a.Method, msg)
+			}
+		}
+	}
+}
+
+// shadowed сообщает, перекрывает ли один из маршрутов часть путей другого:
+// gin выбирает статический сегмент раньше параметра, а *catch-all
+// забирает всё под своим префиксом.
+func shadowed(a, b string) string {
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, y := as[i], bs[i]
+		switch {
+		case x == y:
+			continue
+		case strings.HasPrefix(x, "*"):
+			return fmt.Sprintf("%s is hidden by %s", b, a)
+		case strings.HasPrefix(y, "*"):
+			return fmt.Sprintf("%s is hidden by %s", a, b)
+		case isParam(x) == isParam(y):
+			return ""
+		}
+		// расходятся параметр и статический сегмент; пустой сегмент
+		// (путь со слэшем на конце) параметр и так не принимает
+		param, static, segments := a, b, bs[:i+1]
+		if isParam(y) {
+			param, static, segments = b, a, as[:i+1]
+		}
+		if segments[i] == "" || !matchRest(as[i+1:], bs[i+1:]) || reserved(segments) {
+			return ""
+		}
+		return fmt.Sprintf("%s never gets %q, taken by %s", param, segments[i], static)
+	}
+	return ""
+}
+
+func isParam(segment string) bool {
+	return strings.HasPrefix(segment, ":")
+}
+
+// matchRest сообщает, может ли один путь совпасть с другим после сегмента,
+// в котором они разошлись.
+func matchRest(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] && !isParam(a[i]) && !isParam(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// reserved проверяет статический сегмент по reservedSegments без префикса версии.
+func reserved(segments []string) bool {
+	p := strings.Join(segments, "/")
+	for _, prefix := range apiPrefixes {
+		if rest, ok := strings.CutPrefix(p, prefix); ok && reservedSegments[rest] {
+			return true
+		}
+	}
+	return false
+}
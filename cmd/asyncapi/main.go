@@ -0,0 +1,32 @@
+// Команда asyncapi выгружает AsyncAPI-документ WebSocket-протокола офферов.
+//
+//	go run ./cmd/asyncapi -o cmd/api/docs/asyncapi.json
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/offer"
+)
+
+func main() {
+	out := flag.String("o", "", "файл для документа; по умолчанию stdout")
+	channel := flag.String("channel", "/api/v1/ws/offers", "путь WebSocket-эндпоинта")
+	flag.Parse()
+
+	b, err := json.MarshalIndent(offer.AsyncAPI(*channel), "", "    ")
+	if err != nil {
+		log.Fatalf("failed to encode AsyncAPI document: %v", err)
+	}
+	b = append(b, '\n')
+	if *out == "" {
+		os.Stdout.Write(b)
+		return
+	}
+	if err := os.WriteFile(*out, b, 0o644); err != nil {
+		log.Fatalf("failed to write %s: %v", *out, err)
+	}
+}
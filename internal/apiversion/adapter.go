@@ -0,0 +1,185 @@
+// internal/apiversion/adapter.go
+package apiversion
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Adapter переделывает JSON-ответ обработчика под формат другой версии API.
+// Так один обработчик обслуживает несколько версий. При ошибке клиент
+// получает исходный ответ.
+type Adapter func(status int, body []byte) (int, []byte, error)
+
+// Adapt применяет adapters по порядку к JSON-ответам маршрута. Остальные
+// ответы (файлы, WebSocket, потоки) проходят без изменений и без буферизации.
+func Adapt(adapters ...Adapter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orig := c.Writer
+		w := &adaptWriter{ResponseWriter: orig, status: http.StatusOK}
+		c.Writer = w
+		defer func() { c.Writer = orig }()
+
+		c.Next()
+
+		if w.mode == modeBuffer {
+			status, body := w.status, w.buf.Bytes()
+			for _, adapt := range adapters {
+				s, b, err := adapt(status, body)
+				if err != nil {
+					status, body = w.status, w.buf.Bytes()
+					break
+				}
+				status, body = s, b
+			}
+			orig.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			orig.WriteHeader(status)
+			orig.Write(body)
+			return
+		}
+		if w.mode == modeUndecided {
+			// тело не писали (204, 304 и т.п.) — передаём только статус
+			orig.WriteHeader(w.status)
+		}
+	}
+}
+
+const (
+	modeUndecided = iota
+	modeBuffer
+	modePassthrough
+)
+
+// adaptWriter копит JSON-ответ, а всё остальное сразу пропускает дальше.
+// Режим выбирается при первой записи тела по Content-Type.
+type adaptWriter struct {
+	gin.ResponseWriter
+	status int
+	mode   int
+	buf    bytes.Buffer
+}
+
+func (w *adaptWriter) WriteHeader(code int) {
+	if w.mode == modePassthrough {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if code > 0 {
+		w.status = code
+	}
+}
+
+func (w *adaptWriter) WriteHeaderNow() {
+	if w.mode == modePassthrough {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *adaptWriter) decide() {
+	if w.mode != modeUndecided {
+		return
+	}
+	if strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		w.mode = modeBuffer
+		return
+	}
+	w.mode = modePassthrough
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+func (w *adaptWriter) Write(b []byte) (int, error) {
+	w.decide()
+	if w.mode == modeBuffer {
+		return w.buf.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *adaptWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *adaptWriter) Status() int {
+	if w.mode == modePassthrough {
+		return w.ResponseWriter.Status()
+	}
+	return w.status
+}
+
+func (w *adaptWriter) Written() bool {
+	return w.mode != modeUndecided
+}
+
+func (w *adaptWriter) Size() int {
+	if w.mode == modeBuffer {
+		return w.buf.Len()
+	}
+	return w.ResponseWriter.Size()
+}
+
+func (w *adaptWriter) Flush() {
+	// потоковый ответ нельзя буферизовать
+	w.decide()
+	if w.mode == modePassthrough {
+		w.ResponseWriter.Flush()
+	}
+}
+
+// Envelope — формат ответа v2: полезные данные отделены от ошибки.
+type Envelope struct {
+	Data  json.RawMessage `json:"data"`
+	Error *EnvelopeError  `json:"error,omitempty"`
+}
+
+// EnvelopeError — ошибка в формате v2.
+type EnvelopeError struct {
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+// EnvelopeV2 переводит ответ v1 {success, message, errors, ...данные}
+// в {data: {...данные}, error: {message, fields}}. Ответы без поля success
+// (например, GraphQL) не меняются.
+func EnvelopeV2(status int, body []byte) (int, []byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return 0, nil, err
+	}
+	rawSuccess, ok := fields["success"]
+	if !ok {
+		return status, body, nil
+	}
+	var success bool
+	if err := json.Unmarshal(rawSuccess, &success); err != nil {
+		return 0, nil, err
+	}
+
+	var env Envelope
+	if !success {
+		env.Error = &EnvelopeError{}
+		json.Unmarshal(fields["message"], &env.Error.Message)
+		json.Unmarshal(fields["errors"], &env.Error.Fields)
+	}
+	delete(fields, "success")
+	delete(fields, "message")
+	delete(fields, "errors")
+
+	env.Data = json.RawMessage("null")
+	if len(fields) > 0 {
+		data, err := json.Marshal(fields)
+		if err != nil {
+			return 0, nil, err
+		}
+		env.Data = data
+	}
+	out, err := json.Marshal(env)
+	if err != nil {
+		return 0, nil, err
+	}
+	return status, out, nil
+}
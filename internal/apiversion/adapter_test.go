@@ -0,0 +1,149 @@
+// internal/apiversion/adapter_test.go
+package apiversion
+
+import (
+	"bufio"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+func TestEnvelopeV2(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"data", `{"success":true,"order":{"id":"1"},"total":2}`, `{"data":{"order":{"id":"1"},"total":2}}`},
+		{"no data", `{"success":true,"message":"удалено"}`, `{"data":null}`},
+		{"error", `{"success":false,"message":"некорректный запрос","errors":{"price":"обязательное поле"}}`,
+			`{"data":null,"error":{"message":"некорректный запрос","fields":{"price":"обязательное поле"}}}`},
+		{"error with data", `{"success":false,"message":"конфликт","current":{"id":"1"}}`,
+			`{"data":{"current":{"id":"1"}},"error":{"message":"конфликт"}}`},
+		{"not a v1 response", `{"data":{"me":null}}`, `{"data":{"me":null}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, out, err := EnvelopeV2(http.StatusTeapot, []byte(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status != http.StatusTeapot || string(out) != tt.want {
+				t.Fatalf("got %d %s, want %s", status, out, tt.want)
+			}
+		})
+	}
+	for _, bad := range []string{`[1,2]`, `{"success":"yes"}`, `not json`} {
+		if _, _, err := EnvelopeV2(http.StatusOK, []byte(bad)); err == nil {
+			t.Errorf("EnvelopeV2(%s) accepted", bad)
+		}
+	}
+}
+
+// newAdaptRouter регистрирует handler на /x за Adapt(adapters...).
+func newAdaptRouter(handler gin.HandlerFunc, adapters ...Adapter) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/x", Adapt(adapters...), handler)
+	return r
+}
+
+func get(r http.Handler) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/x", nil))
+	return w
+}
+
+func TestAdapt(t *testing.T) {
+	failing := func(int, []byte) (int, []byte, error) { return 0, nil, errors.New("broken") }
+	tests := []struct {
+		name     string
+		handler  gin.HandlerFunc
+		adapters []Adapter
+		code     int
+		body     string
+	}{
+		{"json is adapted", func(c *gin.Context) {
+			c.JSON(http.StatusCreated, gin.H{"success": true, "id": "1"})
+		}, []Adapter{EnvelopeV2}, http.StatusCreated, `{"data":{"id":"1"}}`},
+		{"adapter error keeps the original", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"success": true})
+		}, []Adapter{failing}, http.StatusOK, `{"success":true}`},
+		{"non-json passes through", func(c *gin.Context) {
+			c.String(http.StatusAccepted, "plain")
+		}, []Adapter{failing}, http.StatusAccepted, "plain"},
+		{"empty body keeps the status", func(c *gin.Context) {
+			c.Status(http.StatusNotModified)
+		}, []Adapter{EnvelopeV2}, http.StatusNotModified, ""},
+		{"aborted request is adapted", func(c *gin.Context) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"success": false, "message": "нет доступа"})
+		}, []Adapter{EnvelopeV2}, http.StatusForbidden, `{"data":null,"error":{"message":"нет доступа"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := get(newAdaptRouter(tt.handler, tt.adapters...))
+			if w.Code != tt.code || w.Body.String() != tt.body {
+				t.Fatalf("got %d %s, want %d %s", w.Code, w.Body, tt.code, tt.body)
+			}
+		})
+	}
+}
+
+// Поток событий не буферизуется: первое событие доходит до клиента, пока
+// обработчик ещё работает.
+func TestAdaptStreamsEvents(t *testing.T) {
+	release := make(chan struct{})
+	r := newAdaptRouter(func(c *gin.Context) {
+		c.Header("Content-Type", "text/event-stream")
+		c.SSEvent("offer", "first")
+		c.Writer.Flush()
+		<-release
+	}, EnvelopeV2)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+	defer close(release)
+
+	resp, err := http.Get(srv.URL + "/x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil || line != "event:offer\n" {
+		t.Fatalf("got %q, %v", line, err)
+	}
+}
+
+func TestAdaptHijack(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	r := newAdaptRouter(func(c *gin.Context) {
+		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		typ, msg, err := conn.ReadMessage()
+		if err == nil {
+			conn.WriteMessage(typ, append([]byte("echo:"), msg...))
+		}
+	}, EnvelopeV2)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/x", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("hi")); err != nil {
+		t.Fatal(err)
+	}
+	if _, msg, err := conn.ReadMessage(); err != nil || string(msg) != "echo:hi" {
+		t.Fatalf("got %q, %v", msg, err)
+	}
+}
@@ -0,0 +1,53 @@
+// internal/apiversion/deprecation.go
+package apiversion
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecation описывает маршруты, запланированные к удалению.
+type Deprecation struct {
+	// At — с какого момента маршрут считается устаревшим (RFC 9745).
+	At time.Time
+	// Sunset — когда маршрут перестанет работать (RFC 8594). После этой даты
+	// запросы получают 410 Gone.
+	Sunset time.Time
+	// Successor возвращает путь к замене для Link с rel="successor-version".
+	Successor func(c *gin.Context) string
+}
+
+// ReplacePrefix строит Successor, заменяя префикс пути запроса.
+func ReplacePrefix(from, to string) func(c *gin.Context) string {
+	return func(c *gin.Context) string {
+		return to + strings.TrimPrefix(c.Request.URL.Path, from)
+	}
+}
+
+// Deprecated выставляет заголовки Deprecation, Sunset и Link. Нулевые даты
+// пропускаются.
+func Deprecated(d Deprecation) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !d.At.IsZero() {
+			c.Header("Deprecation", "@"+strconv.FormatInt(d.At.Unix(), 10))
+		}
+		if !d.Sunset.IsZero() {
+			c.Header("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+		}
+		if d.Successor != nil {
+			c.Header("Link", "<"+d.Successor(c)+`>; rel="successor-version"`)
+		}
+		if !d.Sunset.IsZero() && time.Now().After(d.Sunset) {
+			c.AbortWithStatusJSON(http.StatusGone, gin.H{
+				"success": false,
+				"message": "эта версия API больше не поддерживается",
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,70 @@
+// internal/apiversion/deprecation_test.go
+package apiversion
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDeprecated(t *testing.T) {
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	future := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	past := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name        string
+		d           Deprecation
+		code        int
+		deprecation string
+		sunset      string
+		link        string
+	}{
+		{"nothing set", Deprecation{}, http.StatusOK, "", "", ""},
+		{"all headers", Deprecation{At: at, Sunset: future, Successor: ReplacePrefix("/api", "/api/v1")},
+			http.StatusOK, "@1767225600", future.UTC().Format(http.TimeFormat), `</api/v1/orders/1>; rel="successor-version"`},
+		{"after sunset", Deprecation{At: at, Sunset: past, Successor: ReplacePrefix("/api", "/api/v1")},
+			http.StatusGone, "@1767225600", past.UTC().Format(http.TimeFormat), `</api/v1/orders/1>; rel="successor-version"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.GET("/api/orders/:id", Middleware(V1), Deprecated(tt.d), func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"success": true})
+			})
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/orders/1", nil))
+			if w.Code != tt.code {
+				t.Fatalf("got %d %s, want %d", w.Code, w.Body, tt.code)
+			}
+			for header, want := range map[string]string{
+				"Deprecation": tt.deprecation,
+				"Sunset":      tt.sunset,
+				"Link":        tt.link,
+				HeaderVersion: string(V1),
+			} {
+				if got := w.Header().Get(header); got != want {
+					t.Errorf("%s: got %q, want %q", header, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	var got []Version
+	record := func(c *gin.Context) { got = append(got, FromContext(c)) }
+	Group(r, V2).GET("/x", record)
+	r.GET("/x", record)
+	for _, target := range []string{"/v2/x", "/x"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+	if len(got) != 2 || got[0] != V2 || got[1] != V1 {
+		t.Fatalf("got %v, want [v2 v1]", got)
+	}
+}
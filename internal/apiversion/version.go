@@ -0,0 +1,42 @@
+// internal/apiversion/version.go
+package apiversion
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// Version — версия публичного API, она же префикс маршрутов (/api/v1).
+type Version string
+
+const (
+	V1 Version = "v1"
+	V2 Version = "v2"
+)
+
+// HeaderVersion — заголовок ответа с версией API, обработавшей запрос.
+const HeaderVersion = "API-Version"
+
+const ctxKey = "apiVersion"
+
+// Middleware помечает запрос версией API.
+func Middleware(v Version) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(ctxKey, v)
+		c.Header(HeaderVersion, string(v))
+		c.Next()
+	}
+}
+
+// FromContext возвращает версию API запроса. Для маршрутов без версии — V1.
+func FromContext(c *gin.Context) Version {
+	if v, ok := c.Get(ctxKey); ok {
+		return v.(Version)
+	}
+	return V1
+}
+
+// Group создаёт группу маршрутов версии v внутри parent с общими middleware.
+// Дополнительные middleware (например, Adapt) идут после Middleware(v).
+func Group(parent gin.IRouter, v Version, handlers ...gin.HandlerFunc) *gin.RouterGroup {
+	return parent.Group("/"+string(v), append([]gin.HandlerFunc{Middleware(v)}, handlers...)...)
+}
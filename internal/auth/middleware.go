@@ -6,35 +6,38 @@ import (
 	"github.com/Ostap00034/course-work-backend-api-gateway/util"
 	"github.com/Ostap00034/course-work-backend-auth-service/util/jwt"
 	"github.com/gin-gonic/gin"
-	"google.golang.org/grpc/metadata"
 )
 
 // Middleware прокидывает токен из cookie в gRPC-metadata.
 func Middleware() gin.HandlerFunc {
-    return util.CookieToMetadata()
+	return util.CookieToMetadata()
 }
 
-// AdminOnly проверяет, что в метадате gRPC (или JWT-claim) есть роль admin.
+// AdminOnly проверяет, что в JWT из cookie есть роль admin.
 func AdminOnly() gin.HandlerFunc {
-    return func(c *gin.Context) {
-        // вытянуть токен из cookie → metadata (у вас уже есть CookieToMetadata)
-        // здесь просто читаем claims из контекста
-        md, ok := metadata.FromIncomingContext(c.Request.Context())
-        if !ok {
-            c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "нет метадаты"})
-            return
-        }
-        toks := md["authorization"]
-        if len(toks) == 0 {
-            c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "нет токена"})
-            return
-        }
-        claims, err := jwt.ParseToken(toks[0])
-        if err != nil || claims.Role != "admin" {
-            c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "доступ запрещён"})
-            return
-        }
-        // прокидываем в контекст пользователя, если нужно
-        c.Next()
-    }
+	return func(c *gin.Context) {
+		claims, ok := CurrentUser(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "нет токена"})
+			return
+		}
+		if claims.Role != "admin" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "доступ запрещён"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// CurrentUser разбирает JWT из cookie "token" и возвращает claims пользователя.
+func CurrentUser(c *gin.Context) (*jwt.Claims, bool) {
+	token, ok := util.GetCookie(c, "token")
+	if !ok || token == "" {
+		return nil, false
+	}
+	claims, err := jwt.ParseToken(token)
+	if err != nil {
+		return nil, false
+	}
+	return claims, true
 }
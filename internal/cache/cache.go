@@ -0,0 +1,228 @@
+// internal/cache/cache.go
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Options — параметры кэша.
+type Options struct {
+	TTL     time.Duration // сколько значение считается свежим
+	Stale   time.Duration // сколько после TTL можно отдавать устаревшее значение, обновляя его в фоне
+	MaxSize int           // максимальное число записей, старые вытесняются по LRU
+	// RefreshTimeout ограничивает загрузку значения. Загрузка не отменяется
+	// вместе с запросом, который её начал: её результат ждут другие запросы
+	// того же ключа, а обновление устаревшей записи и вовсе идёт в фоне.
+	RefreshTimeout time.Duration
+}
+
+type entry[V any] struct {
+	key        string
+	value      V
+	storedAt   time.Time
+	refreshing bool
+}
+
+// Cache — in-process кэш с TTL, ограничением размера и stale-while-revalidate.
+type Cache[V any] struct {
+	opts  Options
+	mu    sync.Mutex
+	ll    *list.List
+	items map[string]*list.Element
+	// loads — ключи, которые сейчас загружаются. Delete и Purge увеличивают
+	// gen загрузки, чтобы начатая до инвалидации загрузка не вернула в кэш
+	// устаревшие данные; загрузки других ключей при этом не страдают.
+	loads map[string]*loading
+	// group склеивает одновременные загрузки ключа при промахе.
+	group singleflight.Group
+	now   func() time.Time
+}
+
+// loading — идущие загрузки одного ключа: n — сколько их, gen — сколько
+// раз ключ инвалидировали, пока они идут.
+type loading struct {
+	n   int
+	gen uint64
+}
+
+// New создаёт кэш.
+func New[V any](opts Options) *Cache[V] {
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = 1000
+	}
+	if opts.RefreshTimeout <= 0 {
+		opts.RefreshTimeout = 5 * time.Second
+	}
+	return &Cache[V]{
+		opts:  opts,
+		ll:    list.New(),
+		items: make(map[string]*list.Element),
+		loads: make(map[string]*loading),
+		now:   time.Now,
+	}
+}
+
+// GetOrLoad возвращает значение по ключу. Свежее значение отдаётся сразу;
+// устаревшее, но в пределах Stale, отдаётся сразу и обновляется в фоне;
+// иначе значение загружается через load, причём одновременные промахи по
+// одному ключу ждут одну общую загрузку.
+//
+// load получает контекст со значениями ctx (в том числе исходящей metadata
+// с токеном пользователя), но без его отмены и с таймаутом RefreshTimeout:
+// загрузку может ждать не только начавший её запрос, а фоновое обновление
+// идёт уже после ответа. Обновление выполняется с metadata запроса, который
+// застал запись устаревшей.
+func (c *Cache[V]) GetOrLoad(ctx context.Context, key string, load func(ctx context.Context) (V, error)) (V, error) {
+	c.mu.Lock()
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry[V])
+		age := c.now().Sub(e.storedAt)
+		switch {
+		case age < c.opts.TTL:
+			c.ll.MoveToFront(el)
+			v := e.value
+			c.mu.Unlock()
+			return v, nil
+		case age < c.opts.TTL+c.opts.Stale:
+			c.ll.MoveToFront(el)
+			if !e.refreshing {
+				e.refreshing = true
+				go c.refresh(ctx, key, c.begin(key), load)
+			}
+			v := e.value
+			c.mu.Unlock()
+			return v, nil
+		}
+	}
+	c.mu.Unlock()
+
+	ch := c.group.DoChan(key, func() (any, error) {
+		c.mu.Lock()
+		gen := c.begin(key)
+		c.mu.Unlock()
+
+		ctx, cancel := c.detach(ctx)
+		defer cancel()
+		v, err := load(ctx)
+		if err != nil {
+			c.mu.Lock()
+			c.end(key)
+			c.mu.Unlock()
+			return nil, err
+		}
+		c.set(key, v, gen)
+		return v, nil
+	})
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			var zero V
+			return zero, res.Err
+		}
+		return res.Val.(V), nil
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// refresh обновляет устаревшую запись в фоне, см. GetOrLoad.
+func (c *Cache[V]) refresh(ctx context.Context, key string, gen uint64, load func(ctx context.Context) (V, error)) {
+	ctx, cancel := c.detach(ctx)
+	defer cancel()
+
+	v, err := load(ctx)
+	if err != nil {
+		c.mu.Lock()
+		c.end(key)
+		if el, ok := c.items[key]; ok {
+			el.Value.(*entry[V]).refreshing = false
+		}
+		c.mu.Unlock()
+		return
+	}
+	c.set(key, v, gen)
+}
+
+// detach возвращает контекст загрузки: значения ctx без его отмены,
+// ограниченные RefreshTimeout.
+func (c *Cache[V]) detach(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), c.opts.RefreshTimeout)
+}
+
+// begin отмечает начало загрузки key и возвращает текущий gen ключа.
+// Вызывается под c.mu.
+func (c *Cache[V]) begin(key string) uint64 {
+	l := c.loads[key]
+	if l == nil {
+		l = &loading{}
+		c.loads[key] = l
+	}
+	l.n++
+	return l.gen
+}
+
+// end отмечает конец загрузки key и возвращает gen ключа. Вызывается под c.mu.
+func (c *Cache[V]) end(key string) uint64 {
+	l := c.loads[key]
+	gen := l.gen
+	if l.n--; l.n == 0 {
+		delete(c.loads, key)
+	}
+	return gen
+}
+
+// set сохраняет значение, если с момента начала загрузки key не инвалидировали.
+func (c *Cache[V]) set(key string, v V, gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.end(key) != gen {
+		return
+	}
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry[V])
+		e.value, e.storedAt, e.refreshing = v, c.now(), false
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.ll.PushFront(&entry[V]{key: key, value: v, storedAt: c.now()})
+	for c.ll.Len() > c.opts.MaxSize {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*entry[V]).key)
+	}
+}
+
+// Delete удаляет записи по ключам.
+func (c *Cache[V]) Delete(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		if l := c.loads[key]; l != nil {
+			l.gen++
+		}
+		// новые промахи не должны присоединяться к загрузке, начатой до удаления
+		c.group.Forget(key)
+		if el, ok := c.items[key]; ok {
+			c.ll.Remove(el)
+			delete(c.items, key)
+		}
+	}
+}
+
+// Purge очищает кэш целиком.
+func (c *Cache[V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, l := range c.loads {
+		l.gen++
+		c.group.Forget(key)
+	}
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+}
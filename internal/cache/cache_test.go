@@ -0,0 +1,244 @@
+// internal/cache/cache_test.go
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// clock — ручные часы для кэша.
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestCache(opts Options) (*Cache[string], *clock) {
+	c := New[string](opts)
+	clk := &clock{now: time.Unix(1_000_000, 0)}
+	c.now = clk.Now
+	return c, clk
+}
+
+// loader возвращает по очереди values и считает вызовы.
+type loader struct {
+	calls  atomic.Int32
+	values []string
+}
+
+func (l *loader) load(context.Context) (string, error) {
+	n := int(l.calls.Add(1))
+	return l.values[min(n, len(l.values))-1], nil
+}
+
+func get(t *testing.T, c *Cache[string], key string, load func(context.Context) (string, error)) string {
+	t.Helper()
+	v, err := c.GetOrLoad(context.Background(), key, load)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+// waitFor ждёт, пока cond не станет истинным.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestTTL(t *testing.T) {
+	c, clk := newTestCache(Options{TTL: time.Minute})
+	l := &loader{values: []string{"v1", "v2"}}
+
+	if v := get(t, c, "k", l.load); v != "v1" {
+		t.Fatalf("got %s, want v1", v)
+	}
+	clk.Advance(59 * time.Second)
+	if v := get(t, c, "k", l.load); v != "v1" || l.calls.Load() != 1 {
+		t.Fatalf("fresh entry: got %s after %d loads", v, l.calls.Load())
+	}
+	// без Stale просроченная запись загружается заново синхронно
+	clk.Advance(time.Second)
+	if v := get(t, c, "k", l.load); v != "v2" || l.calls.Load() != 2 {
+		t.Fatalf("expired entry: got %s after %d loads", v, l.calls.Load())
+	}
+}
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	c, clk := newTestCache(Options{TTL: time.Minute, Stale: time.Minute})
+	release := make(chan struct{})
+	var calls atomic.Int32
+	var token atomic.Value
+	load := func(ctx context.Context) (string, error) {
+		if calls.Add(1) == 1 {
+			return "v1", nil
+		}
+		md, _ := metadata.FromOutgoingContext(ctx)
+		token.Store(md.Get("authorization"))
+		<-release
+		return "v2", nil
+	}
+	get(t, c, "k", load)
+	clk.Advance(90 * time.Second)
+
+	// запрос, заставший запись устаревшей, получает её сразу и отменяется,
+	// а обновление продолжается с его metadata
+	ctx, cancel := context.WithCancel(metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", "t1")))
+	v, err := c.GetOrLoad(ctx, "k", load)
+	cancel()
+	if err != nil || v != "v1" {
+		t.Fatalf("stale read: got %s, %v, want v1", v, err)
+	}
+	if v := get(t, c, "k", load); v != "v1" {
+		t.Fatalf("during refresh: got %s, want v1", v)
+	}
+	close(release)
+	waitFor(t, func() bool { return get(t, c, "k", load) == "v2" })
+	if calls.Load() != 2 {
+		t.Fatalf("%d loads, want one refresh", calls.Load())
+	}
+	if got, _ := token.Load().([]string); len(got) != 1 || got[0] != "t1" {
+		t.Fatalf("refresh ran with authorization %v, want t1", got)
+	}
+
+	// за пределами Stale запись уже не отдаётся
+	clk.Advance(2 * time.Minute)
+	if _, err := c.GetOrLoad(context.Background(), "k", func(context.Context) (string, error) {
+		return "", errors.New("down")
+	}); err == nil {
+		t.Fatal("expired entry served")
+	}
+}
+
+func TestSingleflightOnMiss(t *testing.T) {
+	c, _ := newTestCache(Options{TTL: time.Minute})
+	release := make(chan struct{})
+	var calls atomic.Int32
+	load := func(ctx context.Context) (string, error) {
+		calls.Add(1)
+		<-release
+		return "v", ctx.Err()
+	}
+
+	// первый ждущий уходит, не дождавшись: загрузка для остальных не отменяется
+	ctx, cancel := context.WithCancel(context.Background())
+	gone := make(chan error, 1)
+	go func() {
+		_, err := c.GetOrLoad(ctx, "k", load)
+		gone <- err
+	}()
+	waitFor(t, func() bool { return calls.Load() == 1 })
+
+	const n = 10
+	var wg sync.WaitGroup
+	results := make(chan string, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := c.GetOrLoad(context.Background(), "k", load)
+			if err != nil {
+				t.Error(err)
+			}
+			results <- v
+		}()
+	}
+	cancel()
+	if err := <-gone; err != context.Canceled {
+		t.Fatalf("cancelled caller got %v", err)
+	}
+	close(release)
+	wg.Wait()
+	close(results)
+	for v := range results {
+		if v != "v" {
+			t.Fatalf("got %q, want v", v)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("%d loads, want 1", calls.Load())
+	}
+}
+
+func TestDeleteDuringLoad(t *testing.T) {
+	c, _ := newTestCache(Options{TTL: time.Minute})
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan string)
+	go func() {
+		v, _ := c.GetOrLoad(context.Background(), "k", func(context.Context) (string, error) {
+			close(started)
+			<-release
+			return "old", nil
+		})
+		done <- v
+	}()
+	<-started
+	// запись изменили, пока шла загрузка: новый промах не ждёт старую загрузку
+	c.Delete("k")
+	if v := get(t, c, "k", func(context.Context) (string, error) { return "new", nil }); v != "new" {
+		t.Fatalf("after delete: got %s, want new", v)
+	}
+	close(release)
+	if v := <-done; v != "old" {
+		t.Fatalf("first caller got %s", v)
+	}
+	// устаревшая загрузка не перезаписала кэш
+	if v := get(t, c, "k", func(context.Context) (string, error) { return "reloaded", nil }); v != "new" {
+		t.Fatalf("got %s, want new", v)
+	}
+
+	c.Purge()
+	if v := get(t, c, "k", func(context.Context) (string, error) { return "after purge", nil }); v != "after purge" {
+		t.Fatalf("got %s, want after purge", v)
+	}
+}
+
+func TestErrorsAreNotCached(t *testing.T) {
+	c, _ := newTestCache(Options{TTL: time.Minute})
+	if _, err := c.GetOrLoad(context.Background(), "k", func(context.Context) (string, error) {
+		return "", errors.New("down")
+	}); err == nil {
+		t.Fatal("no error")
+	}
+	if v := get(t, c, "k", func(context.Context) (string, error) { return "v", nil }); v != "v" {
+		t.Fatalf("got %s, want v", v)
+	}
+}
+
+func TestLRU(t *testing.T) {
+	c, _ := newTestCache(Options{TTL: time.Minute, MaxSize: 2})
+	l := &loader{values: []string{"a", "b", "c", "a2"}}
+	get(t, c, "a", l.load)
+	get(t, c, "b", l.load)
+	get(t, c, "a", l.load) // a свежее b
+	get(t, c, "c", l.load) // вытесняет b
+	if v := get(t, c, "a", l.load); v != "a" {
+		t.Fatalf("a evicted: got %s", v)
+	}
+	if l.calls.Load() != 3 {
+		t.Fatalf("%d loads, want 3", l.calls.Load())
+	}
+}
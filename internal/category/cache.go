@@ -0,0 +1,82 @@
+package category
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/cache"
+	categorypbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/category/v1"
+)
+
+const listKey = "list"
+
+// ReadCache кэширует ответы сервиса категорий для GET-запросов.
+// Шлюз сбрасывает кэш сам, когда через него создают, меняют или удаляют
+// категорию; изменения в обход шлюза становятся видны по истечении TTL.
+// Записи общие для всех пользователей: сервис отдаёт всем одни и те же
+// категории. Загрузки идут с metadata запроса, который их вызвал, поэтому
+// handler передаёт контекст запроса, а не *gin.Context.
+type ReadCache struct {
+	opts  cache.Options
+	list  *cache.Cache[*categorypbv1.GetCategoriesResponse]
+	items *cache.Cache[*categorypbv1.GetCategoryByIdResponse]
+}
+
+// NewReadCache создаёт кэш категорий.
+func NewReadCache(opts cache.Options) *ReadCache {
+	return &ReadCache{
+		opts:  opts,
+		list:  cache.New[*categorypbv1.GetCategoriesResponse](opts),
+		items: cache.New[*categorypbv1.GetCategoryByIdResponse](opts),
+	}
+}
+
+// Categories возвращает список категорий из кэша или из сервиса.
+func (c *ReadCache) Categories(ctx context.Context, client categorypbv1.CategoryServiceClient) (*categorypbv1.GetCategoriesResponse, error) {
+	return c.list.GetOrLoad(ctx, listKey, func(ctx context.Context) (*categorypbv1.GetCategoriesResponse, error) {
+		return client.GetCategories(ctx, &categorypbv1.GetCategoriesRequest{})
+	})
+}
+
+// Category возвращает категорию из кэша или из сервиса. Ошибки не кэшируются.
+func (c *ReadCache) Category(ctx context.Context, client categorypbv1.CategoryServiceClient, id string) (*categorypbv1.GetCategoryByIdResponse, error) {
+	return c.items.GetOrLoad(ctx, id, func(ctx context.Context) (*categorypbv1.GetCategoryByIdResponse, error) {
+		return client.GetCategoryById(ctx, &categorypbv1.GetCategoryByIdRequest{Id: id})
+	})
+}
+
+// Invalidate сбрасывает список категорий и перечисленные категории.
+func (c *ReadCache) Invalidate(ids ...string) {
+	c.list.Delete(listKey)
+	if len(ids) > 0 {
+		c.items.Delete(ids...)
+	}
+}
+
+// setCacheHeaders выставляет Cache-Control в соответствии с настройками кэша.
+// Ответы доступны только авторизованным пользователям, поэтому private.
+func (c *ReadCache) setCacheHeaders(ctx *gin.Context) {
+	ctx.Header("Cache-Control", fmt.Sprintf("private, max-age=%d, stale-while-revalidate=%d",
+		int(c.opts.TTL/time.Second), int(c.opts.Stale/time.Second)))
+}
+
+// parentsVersion сериализует связи категорий для ETag дерева.
+func parentsVersion(parents map[string]string) string {
+	ids := make([]string, 0, len(parents))
+	for id := range parents {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	out := make([]byte, 0, len(ids)*74)
+	for _, id := range ids {
+		out = append(out, id...)
+		out = append(out, '>')
+		out = append(out, parents[id]...)
+		out = append(out, ';')
+	}
+	return string(out)
+}
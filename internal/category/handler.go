@@ -1,21 +1,29 @@
 package category
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/auth"
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/order"
+	"github.com/Ostap00034/course-work-backend-api-gateway/util"
 	categorypbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/category/v1"
 	commonpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/common/v1"
+	orderpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/order/v1"
 )
 
 var validate = validator.New()
 
-func CreateCategoryHandler(client categorypbv1.CategoryServiceClient) gin.HandlerFunc {
+func CreateCategoryHandler(client categorypbv1.CategoryServiceClient, parents *ParentStore, cache *ReadCache) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		var req createCategoryRequest
 		if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -31,6 +39,8 @@ func CreateCategoryHandler(client categorypbv1.CategoryServiceClient) gin.Handle
 						if fe.Tag() == "required" {
 							errs["description"] = "описание обязательно"
 						}
+					case "ParentId":
+						errs["parent_id"] = "неправильный формат id родительской категории"
 					}
 				}
 			} else {
@@ -40,6 +50,10 @@ func CreateCategoryHandler(client categorypbv1.CategoryServiceClient) gin.Handle
 			return
 		}
 
+		if req.ParentId != "" && !checkParent(ctx, client, parents, "", req.ParentId) {
+			return
+		}
+
 		resp, err := client.CreateCategory(ctx, &categorypbv1.CreateCategoryRequest{
 			Name:        req.Name,
 			Description: req.Description,
@@ -57,17 +71,24 @@ func CreateCategoryHandler(client categorypbv1.CategoryServiceClient) gin.Handle
 
 			return
 		}
+		cache.Invalidate()
+
+		if err := parents.SetParent(ctx.Request.Context(), resp.Category.GetId(), req.ParentId); err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse("не удалось сохранить родительскую категорию", nil))
+			return
+		}
 
 		ctx.JSON(http.StatusOK, CategoryResponse{
 			Response: Response{Success: true, Message: "успешно"},
 			Category: resp.Category,
+			ParentId: req.ParentId,
 		})
 	}
 }
 
-func GetCategoriesHandler(client categorypbv1.CategoryServiceClient) gin.HandlerFunc {
+func GetCategoriesHandler(client categorypbv1.CategoryServiceClient, cache *ReadCache) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		res, err := client.GetCategories(ctx, &categorypbv1.GetCategoriesRequest{})
+		res, err := cache.Categories(ctx.Request.Context(), client)
 		if err != nil {
 			if st, ok := status.FromError(err); ok {
 				ctx.JSON(http.StatusInternalServerError, errorResponse(st.Message(), nil))
@@ -76,6 +97,15 @@ func GetCategoriesHandler(client categorypbv1.CategoryServiceClient) gin.Handler
 			}
 			return
 		}
+
+		cache.setCacheHeaders(ctx)
+		etag := util.ETag(res)
+		util.SetETag(ctx, etag)
+		if util.NotModified(ctx, etag) {
+			ctx.Status(http.StatusNotModified)
+			return
+		}
+
 		ctx.JSON(http.StatusOK, CategoriesResponse{
 			Response:   Response{Success: true, Message: "успешно"},
 			Categories: res.Categories,
@@ -83,7 +113,7 @@ func GetCategoriesHandler(client categorypbv1.CategoryServiceClient) gin.Handler
 	}
 }
 
-func GetCategoryHandler(client categorypbv1.CategoryServiceClient) gin.HandlerFunc {
+func GetCategoryHandler(client categorypbv1.CategoryServiceClient, parents *ParentStore, cache *ReadCache) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		id := ctx.Param("id")
 		categoryID, err := uuid.Parse(id)
@@ -92,9 +122,7 @@ func GetCategoryHandler(client categorypbv1.CategoryServiceClient) gin.HandlerFu
 			return
 		}
 
-		resp, err := client.GetCategoryById(ctx, &categorypbv1.GetCategoryByIdRequest{
-			Id: categoryID.String(),
-		})
+		resp, err := cache.Category(ctx.Request.Context(), client, categoryID.String())
 		if err != nil {
 			if st, ok := status.FromError(err); ok {
 				ctx.JSON(http.StatusInternalServerError, errorResponse(st.Message(), nil))
@@ -104,14 +132,22 @@ func GetCategoryHandler(client categorypbv1.CategoryServiceClient) gin.HandlerFu
 			return
 		}
 
-		ctx.JSON(http.StatusOK, CategoryResponse{
-			Response: Response{Success: true, Message: "успешно"},
-			Category: resp.Category,
-		})
+		view, etag, err := categoryView(ctx.Request.Context(), client, parents, cache, resp.Category)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse("внутренняя ошибка сервера", nil))
+			return
+		}
+		cache.setCacheHeaders(ctx)
+		util.SetETag(ctx, etag)
+		if util.NotModified(ctx, etag) {
+			ctx.Status(http.StatusNotModified)
+			return
+		}
+		ctx.JSON(http.StatusOK, view)
 	}
 }
 
-func UpdateCategoryHandler(client categorypbv1.CategoryServiceClient) gin.HandlerFunc {
+func UpdateCategoryHandler(client categorypbv1.CategoryServiceClient, parents *ParentStore, cache *ReadCache) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		id := ctx.Param("id")
 		categoryID, err := uuid.Parse(id)
@@ -130,6 +166,8 @@ func UpdateCategoryHandler(client categorypbv1.CategoryServiceClient) gin.Handle
 						errs["name"] = "название обязательно"
 					case "Description":
 						errs["description"] = "описание обязательно"
+					case "ParentId":
+						errs["parent_id"] = "неправильный формат id родительской категории"
 					}
 				}
 			} else {
@@ -139,18 +177,300 @@ func UpdateCategoryHandler(client categorypbv1.CategoryServiceClient) gin.Handle
 			return
 		}
 
+		if !checkIfMatch(ctx, client, parents, cache, categoryID.String()) {
+			return
+		}
+		if req.ParentId != "" && !checkParent(ctx, client, parents, categoryID.String(), req.ParentId) {
+			return
+		}
+
+		resp, err := client.UpdateCategory(util.WithFieldMask(ctx.Request.Context(), categoryFields...), &categorypbv1.UpdateCategoryRequest{
+			Id: categoryID.String(),
+			Category: &commonpbv1.CategoryData{
+				Name:        req.Name,
+				Description: req.Description,
+			},
+		})
+		if err != nil {
+			if st, ok := status.FromError(err); ok {
+				ctx.JSON(http.StatusInternalServerError, errorResponse(st.Message(), nil))
+			} else {
+				ctx.JSON(http.StatusInternalServerError, errorResponse("внутренняя ошибка сервера", nil))
+			}
+			return
+		}
+		cache.Invalidate(categoryID.String())
+
+		if !setParent(ctx, parents, categoryID.String(), req.ParentId) {
+			return
+		}
+
+		writeCategory(ctx, client, parents, cache, resp.Category)
+	}
+}
+
+func PatchCategoryHandler(client categorypbv1.CategoryServiceClient, parents *ParentStore, cache *ReadCache) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		categoryID, err := uuid.Parse(id)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse("неправильный формат id категории", nil))
+			return
+		}
+
+		var req patchCategoryRequest
+		present, err := util.ReadMergePatch(ctx, &req)
+		if err != nil {
+			if errors.Is(err, util.ErrUnsupportedMediaType) {
+				ctx.JSON(http.StatusUnsupportedMediaType, errorResponse("ожидается application/merge-patch+json", nil))
+			} else {
+				ctx.JSON(http.StatusBadRequest, errorResponse("ошибка валидации", map[string]string{"body": "некорректный запрос"}))
+			}
+			return
+		}
+
+		errs := make(map[string]string)
+		if err := binding.Validator.ValidateStruct(&req); err != nil {
+			if ve, ok := err.(validator.ValidationErrors); ok {
+				for _, fe := range ve {
+					switch fe.Field() {
+					case "Name":
+						errs["name"] = "название не может быть пустым"
+					case "Description":
+						errs["description"] = "описание не может быть пустым"
+					case "ParentId":
+						errs["parent_id"] = "неправильный формат id родительской категории"
+					}
+				}
+			}
+		}
+
 		categoryData := &commonpbv1.CategoryData{}
-		if req.Name != "" {
-			categoryData.Name = req.Name
+		for k := range present {
+			switch k {
+			case "name":
+				if req.Name == nil {
+					errs["name"] = "название нельзя очистить"
+				} else {
+					categoryData.Name = *req.Name
+				}
+			case "description":
+				if req.Description == nil {
+					errs["description"] = "описание нельзя очистить"
+				} else {
+					categoryData.Description = *req.Description
+				}
+			case "parent_id":
+				// null переносит категорию в корень
+			default:
+				errs[k] = "неизвестное поле"
+			}
+		}
+		if len(errs) > 0 {
+			ctx.JSON(http.StatusBadRequest, errorResponse("ошибка валидации", errs))
+			return
+		}
+
+		mask := util.MaskFromPresent(present, categoryFields...)
+		if len(mask) == 0 && !present["parent_id"] {
+			ctx.JSON(http.StatusBadRequest, errorResponse("нет полей для изменения", nil))
+			return
+		}
+
+		if !checkIfMatch(ctx, client, parents, cache, categoryID.String()) {
+			return
+		}
+		parentID, err := parents.Parent(ctx.Request.Context(), categoryID.String())
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse("не удалось прочитать иерархию категорий", nil))
+			return
+		}
+		if present["parent_id"] {
+			parentID = deref(req.ParentId)
+			if parentID != "" && !checkParent(ctx, client, parents, categoryID.String(), parentID) {
+				return
+			}
+		}
+
+		var category *commonpbv1.CategoryData
+		if len(mask) > 0 {
+			resp, err := client.UpdateCategory(util.WithFieldMask(ctx.Request.Context(), mask...), &categorypbv1.UpdateCategoryRequest{
+				Id:       categoryID.String(),
+				Category: categoryData,
+			})
+			if err != nil {
+				if st, ok := status.FromError(err); ok {
+					ctx.JSON(http.StatusInternalServerError, errorResponse(st.Message(), nil))
+				} else {
+					ctx.JSON(http.StatusInternalServerError, errorResponse("внутренняя ошибка сервера", nil))
+				}
+				return
+			}
+			cache.Invalidate(categoryID.String())
+			category = resp.Category
+		} else {
+			// меняется только родитель — сервис категорий не затрагиваем
+			resp, err := client.GetCategoryById(ctx, &categorypbv1.GetCategoryByIdRequest{Id: categoryID.String()})
+			if err != nil {
+				if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
+					ctx.JSON(http.StatusNotFound, errorResponse(st.Message(), nil))
+				} else {
+					ctx.JSON(http.StatusInternalServerError, errorResponse("внутренняя ошибка сервера", nil))
+				}
+				return
+			}
+			category = resp.Category
+		}
+
+		if present["parent_id"] && !setParent(ctx, parents, categoryID.String(), parentID) {
+			return
+		}
+
+		writeCategory(ctx, client, parents, cache, category)
+	}
+}
+
+func DeleteCategoryHandler(client categorypbv1.CategoryServiceClient, orderClient orderpbv1.OrderServiceClient, parents *ParentStore, cache *ReadCache) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		categoryID, err := uuid.Parse(id)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse("неправильный формат id категории", nil))
+			return
 		}
-		if req.Description != "" {
-			categoryData.Description = req.Description
+
+		var req deleteCategoryRequest
+		if err := ctx.ShouldBindQuery(&req); err != nil {
+			errs := make(map[string]string)
+			if ve, ok := err.(validator.ValidationErrors); ok {
+				for _, fe := range ve {
+					switch fe.Field() {
+					case "ReassignTo":
+						errs["reassign_to"] = "неправильный формат id категории"
+					}
+				}
+			} else {
+				// ошибка до валидации — значение не разобралось; кроме force
+				// все параметры строковые
+				errs["force"] = "ожидается true или false"
+			}
+			ctx.JSON(http.StatusBadRequest, errorResponse("ошибка валидации", errs))
+			return
+		}
+		switch {
+		case req.Force && req.ReassignTo != "":
+			ctx.JSON(http.StatusBadRequest, errorResponse("ошибка валидации", map[string]string{
+				"reassign_to": "нельзя передавать вместе с force",
+			}))
+			return
+		case req.ReassignTo == categoryID.String():
+			ctx.JSON(http.StatusBadRequest, errorResponse("ошибка валидации", map[string]string{
+				"reassign_to": "нельзя перенести заказы в удаляемую категорию",
+			}))
+			return
+		}
+
+		if !checkIfMatch(ctx, client, parents, cache, categoryID.String()) {
+			return
+		}
+		if req.ReassignTo != "" && !checkReassignTarget(ctx, client, parents, categoryID.String(), req.ReassignTo) {
+			return
 		}
 
-		resp, err := client.UpdateCategory(ctx, &categorypbv1.UpdateCategoryRequest{
-			Id:       categoryID.String(),
-			Category: categoryData,
+		ordersResp, err := orderClient.GetOrders(ctx.Request.Context(), &orderpbv1.GetOrdersRequest{
+			CategoriesIds: []string{categoryID.String()},
+			ClientId:      uuid.Nil.String(),
+			MasterId:      uuid.Nil.String(),
 		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse("внутренняя ошибка сервера", nil))
+			return
+		}
+		var active []*commonpbv1.OrderData
+		for _, o := range ordersResp.Orders {
+			if o.GetCategoryId() == categoryID.String() && order.IsActiveStatus(o.GetStatus()) {
+				active = append(active, o)
+			}
+		}
+
+		switch {
+		case len(active) == 0 || req.Force:
+		case req.ReassignTo != "":
+			// переносим все заказы, даже если часть не удалась, и сообщаем,
+			// какие перенесены, а какие нет; категория тогда не удаляется,
+			// и повторный запрос перенесёт оставшиеся
+			maskCtx := util.WithFieldMask(ctx.Request.Context(), "category_id")
+			resp := ReassignResponse{Moved: []string{}, Failed: []string{}}
+			for _, o := range active {
+				if _, err := orderClient.UpdateOrder(maskCtx, &orderpbv1.UpdateOrderRequest{
+					Id:         o.GetId(),
+					CategoryId: req.ReassignTo,
+				}); err != nil {
+					resp.Failed = append(resp.Failed, o.GetId())
+				} else {
+					resp.Moved = append(resp.Moved, o.GetId())
+				}
+			}
+			if len(resp.Failed) > 0 {
+				resp.Response = errorResponse("не удалось перенести часть заказов в другую категорию, категория не удалена", nil)
+				ctx.JSON(http.StatusInternalServerError, resp)
+				return
+			}
+		default:
+			ctx.JSON(http.StatusConflict, errorResponse(
+				fmt.Sprintf("категория используется в незавершённых заказах (%d); передайте force=true или reassign_to", len(active)), nil))
+			return
+		}
+
+		if _, err := client.DeleteCategory(ctx.Request.Context(), &categorypbv1.DeleteCategoryRequest{Id: categoryID.String()}); err != nil {
+			if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
+				ctx.JSON(http.StatusNotFound, errorResponse(st.Message(), nil))
+			} else {
+				ctx.JSON(http.StatusInternalServerError, errorResponse("внутренняя ошибка сервера", nil))
+			}
+			return
+		}
+		cache.Invalidate(categoryID.String())
+		// дочерние категории поднимаются к родителю удалённой
+		if err := parents.Remove(ctx.Request.Context(), categoryID.String()); err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse("не удалось обновить иерархию категорий", nil))
+			return
+		}
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+// checkReassignTarget проверяет, что категория target, куда переносятся
+// заказы удаляемой категории id, существует и не вложена в id. Иначе пишет
+// ответ и возвращает false.
+func checkReassignTarget(ctx *gin.Context, client categorypbv1.CategoryServiceClient, parents *ParentStore, id, target string) bool {
+	if _, err := client.GetCategoryById(ctx.Request.Context(), &categorypbv1.GetCategoryByIdRequest{Id: target}); err != nil {
+		if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
+			ctx.JSON(http.StatusBadRequest, errorResponse("ошибка валидации", map[string]string{
+				"reassign_to": "категория не найдена",
+			}))
+		} else {
+			ctx.JSON(http.StatusInternalServerError, errorResponse("внутренняя ошибка сервера", nil))
+		}
+		return false
+	}
+	snapshot, err := parents.Snapshot(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse("не удалось прочитать иерархию категорий", nil))
+		return false
+	}
+	if createsCycle(snapshot, id, target) {
+		ctx.JSON(http.StatusBadRequest, errorResponse("ошибка валидации", map[string]string{
+			"reassign_to": "нельзя перенести заказы в дочернюю категорию удаляемой",
+		}))
+		return false
+	}
+	return true
+}
+
+func GetCategoryTreeHandler(client categorypbv1.CategoryServiceClient, parents *ParentStore, cache *ReadCache) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		res, err := cache.Categories(ctx.Request.Context(), client)
 		if err != nil {
 			if st, ok := status.FromError(err); ok {
 				ctx.JSON(http.StatusInternalServerError, errorResponse(st.Message(), nil))
@@ -160,17 +480,149 @@ func UpdateCategoryHandler(client categorypbv1.CategoryServiceClient) gin.Handle
 			return
 		}
 
-		ctx.JSON(http.StatusOK, CategoryResponse{
-			Response: Response{Success: true, Message: "успешно"},
-			Category: resp.Category,
+		snapshot, err := parents.Snapshot(ctx.Request.Context())
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse("не удалось прочитать иерархию категорий", nil))
+			return
+		}
+		cache.setCacheHeaders(ctx)
+		etag := util.ETag(res, parentsVersion(snapshot))
+		util.SetETag(ctx, etag)
+		if util.NotModified(ctx, etag) {
+			ctx.Status(http.StatusNotModified)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, CategoryTreeResponse{
+			Response:   Response{Success: true, Message: "успешно"},
+			Categories: buildTree(res.Categories, snapshot),
 		})
 	}
 }
 
-func RegisterHandlers(r gin.IRouter, client categorypbv1.CategoryServiceClient) {
-	r.POST("/", CreateCategoryHandler(client))
-	r.GET("/", GetCategoriesHandler(client))
-	r.GET("/:id", GetCategoryHandler(client))
-	r.PUT("/:id", UpdateCategoryHandler(client))
-	r.DELETE("/:id", GetCategoryHandler(client))
+// checkParent проверяет, что родительская категория существует и что перенос
+// категории id под неё не создаст цикл. Для новой категории id пустой.
+// При ошибке ответ уже записан.
+func checkParent(ctx *gin.Context, client categorypbv1.CategoryServiceClient, parents *ParentStore, id, parentID string) bool {
+	if id != "" {
+		snapshot, err := parents.Snapshot(ctx.Request.Context())
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse("не удалось прочитать иерархию категорий", nil))
+			return false
+		}
+		if createsCycle(snapshot, id, parentID) {
+			ctx.JSON(http.StatusConflict, errorResponse("нельзя перенести категорию в саму себя или в её подкатегорию", nil))
+			return false
+		}
+	}
+	if _, err := client.GetCategoryById(ctx, &categorypbv1.GetCategoryByIdRequest{Id: parentID}); err != nil {
+		if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
+			ctx.JSON(http.StatusBadRequest, errorResponse("ошибка валидации", map[string]string{
+				"parent_id": "родительская категория не найдена",
+			}))
+		} else {
+			ctx.JSON(http.StatusInternalServerError, errorResponse("внутренняя ошибка сервера", nil))
+		}
+		return false
+	}
+	return true
+}
+
+// setParent сохраняет родителя категории. При ошибке ответ уже записан.
+func setParent(ctx *gin.Context, parents *ParentStore, id, parentID string) bool {
+	if err := parents.SetParent(ctx.Request.Context(), id, parentID); err != nil {
+		if errors.Is(err, ErrCycle) {
+			ctx.JSON(http.StatusConflict, errorResponse("нельзя перенести категорию в саму себя или в её подкатегорию", nil))
+		} else {
+			ctx.JSON(http.StatusInternalServerError, errorResponse("не удалось сохранить родительскую категорию", nil))
+		}
+		return false
+	}
+	return true
+}
+
+// deref возвращает значение указателя или пустую строку для nil.
+func deref(p *string) string {
+	if p == nil {
+		return ""
+	}
+	return *p
+}
+
+// categoryView собирает ответ GET /categories/:id и его ETag. В ETag входят
+// родитель и breadcrumbs: переименование предка меняет ответ, хотя сама
+// категория не менялась.
+func categoryView(ctx context.Context, client categorypbv1.CategoryServiceClient, parents *ParentStore, cache *ReadCache, category *commonpbv1.CategoryData) (CategoryResponse, string, error) {
+	snapshot, err := parents.Snapshot(ctx)
+	if err != nil {
+		return CategoryResponse{}, "", err
+	}
+	parentID := snapshot[category.GetId()]
+	crumbs := []Breadcrumb{{Id: category.GetId(), Name: category.GetName()}}
+	if parentID != "" {
+		all, err := cache.Categories(ctx, client)
+		if err != nil {
+			return CategoryResponse{}, "", err
+		}
+		crumbs = breadcrumbs(category.GetId(), all.Categories, snapshot)
+	}
+	extra := []string{parentID}
+	for _, b := range crumbs {
+		extra = append(extra, b.Id, b.Name)
+	}
+	return CategoryResponse{
+		Response:    Response{Success: true, Message: "успешно"},
+		Category:    category,
+		ParentId:    parentID,
+		Breadcrumbs: crumbs,
+	}, util.ETag(category, extra...), nil
+}
+
+// writeCategory отвечает на изменение категории тем же представлением и
+// ETag, что и GET, чтобы ETag годился для следующего If-Match.
+func writeCategory(ctx *gin.Context, client categorypbv1.CategoryServiceClient, parents *ParentStore, cache *ReadCache, category *commonpbv1.CategoryData) {
+	view, etag, err := categoryView(ctx.Request.Context(), client, parents, cache, category)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse("внутренняя ошибка сервера", nil))
+		return
+	}
+	util.SetETag(ctx, etag)
+	ctx.JSON(http.StatusOK, view)
+}
+
+// checkIfMatch сверяет If-Match с текущей версией категории (ETag ответа GET).
+// Без заголовка проверка не выполняется. При ошибке ответ уже записан.
+func checkIfMatch(ctx *gin.Context, client categorypbv1.CategoryServiceClient, parents *ParentStore, cache *ReadCache, id string) bool {
+	if !util.HasIfMatch(ctx) {
+		return true
+	}
+	resp, err := client.GetCategoryById(ctx.Request.Context(), &categorypbv1.GetCategoryByIdRequest{Id: id})
+	if err != nil {
+		if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
+			ctx.JSON(http.StatusNotFound, errorResponse(st.Message(), nil))
+		} else {
+			ctx.JSON(http.StatusInternalServerError, errorResponse("внутренняя ошибка сервера", nil))
+		}
+		return false
+	}
+	_, etag, err := categoryView(ctx.Request.Context(), client, parents, cache, resp.Category)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse("внутренняя ошибка сервера", nil))
+		return false
+	}
+	if !util.IfMatch(ctx, etag) {
+		ctx.JSON(http.StatusPreconditionFailed, errorResponse("категория была изменена, обновите данные и повторите запрос", nil))
+		return false
+	}
+	return true
+}
+
+func RegisterHandlers(r gin.IRouter, client categorypbv1.CategoryServiceClient, orderClient orderpbv1.OrderServiceClient, parents *ParentStore, cache *ReadCache) {
+	r.POST("/", CreateCategoryHandler(client, parents, cache))
+	r.GET("/", GetCategoriesHandler(client, cache))
+	r.GET("/tree", GetCategoryTreeHandler(client, parents, cache))
+	r.GET("/:id", GetCategoryHandler(client, parents, cache))
+	r.PUT("/:id", UpdateCategoryHandler(client, parents, cache))
+	r.PATCH("/:id", PatchCategoryHandler(client, parents, cache))
+	r.DELETE("/:id", auth.AdminOnly(), DeleteCategoryHandler(client, orderClient, parents, cache))
 }
@@ -0,0 +1,295 @@
+// internal/category/handler_test.go
+package category
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/cache"
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/order"
+	"github.com/Ostap00034/course-work-backend-api-gateway/util"
+	categorypbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/category/v1"
+	commonpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/common/v1"
+	orderpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/order/v1"
+	"github.com/Ostap00034/course-work-backend-auth-service/util/jwt"
+)
+
+const (
+	rootID  = "00000000-0000-0000-0000-0000000000a1"
+	childID = "00000000-0000-0000-0000-0000000000a2"
+	leafID  = "00000000-0000-0000-0000-0000000000a3"
+)
+
+// fakeCategories хранит категории в памяти и, как сервис, меняет только поля
+// из маски.
+type fakeCategories struct {
+	categorypbv1.CategoryServiceClient
+	mu         sync.Mutex
+	categories map[string]*commonpbv1.CategoryData
+}
+
+func newFakeCategories(ids ...string) *fakeCategories {
+	f := &fakeCategories{categories: make(map[string]*commonpbv1.CategoryData)}
+	for _, id := range ids {
+		f.categories[id] = &commonpbv1.CategoryData{Id: id, Name: "Категория " + id[len(id)-2:], Description: "Описание"}
+	}
+	return f
+}
+
+func (f *fakeCategories) GetCategories(context.Context, *categorypbv1.GetCategoriesRequest, ...grpc.CallOption) (*categorypbv1.GetCategoriesResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	resp := &categorypbv1.GetCategoriesResponse{}
+	for _, c := range f.categories {
+		resp.Categories = append(resp.Categories, proto.Clone(c).(*commonpbv1.CategoryData))
+	}
+	sort.Slice(resp.Categories, func(i, j int) bool { return resp.Categories[i].Id < resp.Categories[j].Id })
+	return resp, nil
+}
+
+func (f *fakeCategories) GetCategoryById(_ context.Context, req *categorypbv1.GetCategoryByIdRequest, _ ...grpc.CallOption) (*categorypbv1.GetCategoryByIdResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, ok := f.categories[req.Id]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "категория не найдена")
+	}
+	return &categorypbv1.GetCategoryByIdResponse{Category: proto.Clone(c).(*commonpbv1.CategoryData)}, nil
+}
+
+func (f *fakeCategories) UpdateCategory(ctx context.Context, req *categorypbv1.UpdateCategoryRequest, _ ...grpc.CallOption) (*categorypbv1.GetCategoryByIdResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, ok := f.categories[req.Id]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "категория не найдена")
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	for _, field := range strings.Split(strings.Join(md.Get(util.FieldMaskMetadataKey), ","), ",") {
+		switch field {
+		case "name":
+			c.Name = req.Category.GetName()
+		case "description":
+			c.Description = req.Category.GetDescription()
+		}
+	}
+	return &categorypbv1.GetCategoryByIdResponse{Category: proto.Clone(c).(*commonpbv1.CategoryData)}, nil
+}
+
+func (f *fakeCategories) DeleteCategory(_ context.Context, req *categorypbv1.DeleteCategoryRequest, _ ...grpc.CallOption) (*categorypbv1.DeleteCategoryResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.categories[req.Id]; !ok {
+		return nil, status.Error(codes.NotFound, "категория не найдена")
+	}
+	delete(f.categories, req.Id)
+	return &categorypbv1.DeleteCategoryResponse{}, nil
+}
+
+// fakeOrders хранит заказы в памяти; перенос заказов из failing не удаётся.
+type fakeOrders struct {
+	orderpbv1.OrderServiceClient
+	mu      sync.Mutex
+	orders  []*commonpbv1.OrderData
+	failing map[string]bool
+}
+
+func (f *fakeOrders) GetOrders(_ context.Context, req *orderpbv1.GetOrdersRequest, _ ...grpc.CallOption) (*orderpbv1.GetOrdersResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	resp := &orderpbv1.GetOrdersResponse{}
+	for _, o := range f.orders {
+		if o.CategoryId == req.CategoriesIds[0] {
+			resp.Orders = append(resp.Orders, proto.Clone(o).(*commonpbv1.OrderData))
+		}
+	}
+	return resp, nil
+}
+
+func (f *fakeOrders) UpdateOrder(ctx context.Context, req *orderpbv1.UpdateOrderRequest, _ ...grpc.CallOption) (*orderpbv1.GetOrderByIdResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	md, _ := metadata.FromOutgoingContext(ctx)
+	if mask := md.Get(util.FieldMaskMetadataKey); len(mask) != 1 || mask[0] != "category_id" {
+		return nil, status.Error(codes.InvalidArgument, "ожидается маска category_id")
+	}
+	if f.failing[req.Id] {
+		return nil, status.Error(codes.Unavailable, "сервис недоступен")
+	}
+	for _, o := range f.orders {
+		if o.Id == req.Id {
+			o.CategoryId = req.CategoryId
+			return &orderpbv1.GetOrderByIdResponse{Order: proto.Clone(o).(*commonpbv1.OrderData)}, nil
+		}
+	}
+	return nil, status.Error(codes.NotFound, "заказ не найден")
+}
+
+// newTestRouter вешает маршруты категорий; parents задаёт иерархию
+// парами «категория, родитель».
+func newTestRouter(t *testing.T, categories *fakeCategories, parents ...string) (*gin.Engine, *ParentStore) {
+	return newTestRouterWithOrders(t, categories, nil, parents...)
+}
+
+func newTestRouterWithOrders(t *testing.T, categories *fakeCategories, orders orderpbv1.OrderServiceClient, parents ...string) (*gin.Engine, *ParentStore) {
+	t.Helper()
+	store, err := NewParentStore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i+1 < len(parents); i += 2 {
+		if err := store.SetParent(context.Background(), parents[i], parents[i+1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterHandlers(r.Group("/categories"), categories, orders, store, NewReadCache(cache.Options{}))
+	return r, store
+}
+
+func do(r *gin.Engine, method, target, body string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCategoryETagCoversBreadcrumbs(t *testing.T) {
+	categories := newFakeCategories(rootID, childID, leafID)
+	r, _ := newTestRouter(t, categories, childID, rootID, leafID, childID)
+
+	w := do(r, "GET", "/categories/"+leafID, "")
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" {
+		t.Fatalf("got %d %s with ETag %q", w.Code, w.Body, etag)
+	}
+	if !strings.Contains(w.Body.String(), "Категория a1") {
+		t.Fatalf("no breadcrumbs in %s", w.Body)
+	}
+	if w := do(r, "GET", "/categories/"+leafID, "", "If-None-Match", etag); w.Code != http.StatusNotModified {
+		t.Fatalf("unchanged category: got %d, want 304", w.Code)
+	}
+
+	// переименование предка меняет ответ, хотя сама категория та же
+	if w := do(r, "PATCH", "/categories/"+rootID, `{"name":"Новое имя"}`, "Content-Type", "application/merge-patch+json"); w.Code != http.StatusOK {
+		t.Fatalf("rename: got %d %s", w.Code, w.Body)
+	}
+	w = do(r, "GET", "/categories/"+leafID, "", "If-None-Match", etag)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Новое имя") {
+		t.Fatalf("after renaming an ancestor: got %d %s, want 200 with new breadcrumbs", w.Code, w.Body)
+	}
+	etag = w.Header().Get("ETag")
+
+	// ETag из GET годится для If-Match, а ответ на изменение отдаёт ETag следующего GET
+	w = do(r, "PATCH", "/categories/"+leafID, `{"description":"Другое"}`,
+		"Content-Type", "application/merge-patch+json", "If-Match", etag)
+	if w.Code != http.StatusOK {
+		t.Fatalf("If-Match with the GET ETag: got %d %s", w.Code, w.Body)
+	}
+	if got := do(r, "GET", "/categories/"+leafID, "").Header().Get("ETag"); got != w.Header().Get("ETag") {
+		t.Fatalf("PATCH returned ETag %s, GET returns %s", w.Header().Get("ETag"), got)
+	}
+	if w := do(r, "PUT", "/categories/"+leafID, `{"name":"x","description":"y","parent_id":"`+childID+`"}`,
+		"If-Match", etag); w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("stale If-Match: got %d, want 412", w.Code)
+	}
+}
+
+// tokenCookie возвращает заголовок Cookie с JWT пользователя с ролью role.
+func tokenCookie(t *testing.T, role string) string {
+	t.Helper()
+	token, err := jwt.GenerateToken(jwt.NewClaims("00000000-0000-0000-0000-00000000f001", role, time.Now().Add(time.Hour)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "token=" + token
+}
+
+func TestDeleteCategory(t *testing.T) {
+	const otherID = "00000000-0000-0000-0000-0000000000a4"
+	const missingID = "00000000-0000-0000-0000-0000000000ff"
+	tests := []struct {
+		name, target, role string
+		failing            []string
+		code               int
+		body               string
+		deleted            bool
+		categoryOf         map[string]string
+	}{
+		{"force with reassign_to", "/categories/" + rootID + "?force=true&reassign_to=" + otherID, "admin", nil,
+			http.StatusBadRequest, "нельзя передавать вместе с force", false, nil},
+		{"reassign to itself", "/categories/" + rootID + "?reassign_to=" + rootID, "admin", nil,
+			http.StatusBadRequest, "нельзя перенести заказы в удаляемую категорию", false, nil},
+		{"reassign to a missing category", "/categories/" + rootID + "?reassign_to=" + missingID, "admin", nil,
+			http.StatusBadRequest, "категория не найдена", false, nil},
+		{"reassign to a descendant", "/categories/" + rootID + "?reassign_to=" + leafID, "admin", nil,
+			http.StatusBadRequest, "нельзя перенести заказы в дочернюю категорию удаляемой", false, nil},
+		{"reassign_to is checked without active orders", "/categories/" + childID + "?reassign_to=" + missingID, "admin", nil,
+			http.StatusBadRequest, "категория не найдена", false, nil},
+		{"active orders", "/categories/" + rootID, "admin", nil,
+			http.StatusConflict, "незавершённых заказах (2)", false, nil},
+		{"not an admin", "/categories/" + rootID + "?force=true", "client", nil,
+			http.StatusForbidden, "", false, nil},
+		{"force", "/categories/" + rootID + "?force=true", "admin", nil,
+			http.StatusNoContent, "", true, map[string]string{"o1": rootID, "o2": rootID}},
+		{"reassign", "/categories/" + rootID + "?reassign_to=" + otherID, "admin", nil,
+			http.StatusNoContent, "", true, map[string]string{"o1": otherID, "o2": otherID, "o3": rootID}},
+		{"partial reassign", "/categories/" + rootID + "?reassign_to=" + otherID, "admin", []string{"o2"},
+			http.StatusInternalServerError, `"moved":["o1"],"failed":["o2"]`, false, map[string]string{"o1": otherID, "o2": rootID}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			categories := newFakeCategories(rootID, childID, leafID, otherID)
+			orders := &fakeOrders{
+				orders: []*commonpbv1.OrderData{
+					{Id: "o1", CategoryId: rootID, Status: order.StatusOpen},
+					{Id: "o2", CategoryId: rootID, Status: order.StatusInProgress},
+					{Id: "o3", CategoryId: rootID, Status: order.StatusCompleted},
+				},
+				failing: map[string]bool{},
+			}
+			for _, id := range tt.failing {
+				orders.failing[id] = true
+			}
+			r, store := newTestRouterWithOrders(t, categories, orders, childID, rootID, leafID, childID)
+
+			w := do(r, http.MethodDelete, tt.target, "", "Cookie", tokenCookie(t, tt.role))
+			if w.Code != tt.code || !strings.Contains(w.Body.String(), tt.body) {
+				t.Fatalf("got %d %s, want %d with %q", w.Code, w.Body, tt.code, tt.body)
+			}
+			id := strings.TrimPrefix(strings.SplitN(tt.target, "?", 2)[0], "/categories/")
+			if _, exists := categories.categories[id]; exists == tt.deleted {
+				t.Fatalf("category exists = %v, want deleted = %v", exists, tt.deleted)
+			}
+			for _, o := range orders.orders {
+				if want, ok := tt.categoryOf[o.Id]; ok && o.CategoryId != want {
+					t.Errorf("order %s is in %s, want %s", o.Id, o.CategoryId, want)
+				}
+			}
+			// дочерняя категория удалённой поднимается к её родителю
+			if tt.deleted {
+				if p, _ := store.Parent(context.Background(), childID); p != "" {
+					t.Errorf("child of the deleted root has parent %q", p)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package category
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrCycle — перенос категории создал бы цикл.
+var ErrCycle = errors.New("category cycle")
+
+// ParentStore хранит связи категория → родитель. CategoryData в протоколе
+// не содержит parent_id, поэтому иерархию ведёт шлюз. Связи лежат либо
+// в памяти процесса (и в JSON-файле) — это годится для одного экземпляра
+// шлюза, — либо в Redis, общем для всех реплик.
+type ParentStore struct {
+	backend parentBackend
+}
+
+// parentBackend хранит все связи целиком.
+type parentBackend interface {
+	// load возвращает копию связей.
+	load(ctx context.Context) (map[string]string, error)
+	// update атомарно применяет fn к текущим связям. Если fn вернула ошибку,
+	// изменения не сохраняются.
+	update(ctx context.Context, fn func(parents map[string]string) error) error
+}
+
+// NewParentStore создаёт хранилище в памяти и загружает связи из файла path.
+// Пустой path — хранение только в памяти. Реплики шлюза с таким хранилищем
+// не видят изменений друг друга, для них есть NewRedisParentStore.
+func NewParentStore(path string) (*ParentStore, error) {
+	b := &fileParents{path: path, parents: make(map[string]string)}
+	if path == "" {
+		return &ParentStore{backend: b}, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &ParentStore{backend: b}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &b.parents); err != nil {
+			return nil, err
+		}
+	}
+	return &ParentStore{backend: b}, nil
+}
+
+// NewRedisParentStore создаёт хранилище, общее для всех реплик шлюза: связи
+// лежат JSON-объектом в ключе key.
+func NewRedisParentStore(client redis.UniversalClient, key string) *ParentStore {
+	return &ParentStore{backend: &redisParents{client: client, key: key}}
+}
+
+// Parent возвращает id родителя категории или "" для корневой.
+func (s *ParentStore) Parent(ctx context.Context, id string) (string, error) {
+	parents, err := s.backend.load(ctx)
+	if err != nil {
+		return "", err
+	}
+	return parents[id], nil
+}
+
+// Snapshot возвращает копию всех связей.
+func (s *ParentStore) Snapshot(ctx context.Context) (map[string]string, error) {
+	return s.backend.load(ctx)
+}
+
+// SetParent делает parent родителем id. Пустой parent — перенос в корень.
+// Возвращает ErrCycle, если parent является самой категорией или её потомком.
+// Проверка и запись атомарны, в том числе между репликами.
+func (s *ParentStore) SetParent(ctx context.Context, id, parent string) error {
+	return s.backend.update(ctx, func(parents map[string]string) error {
+		if parent == "" {
+			delete(parents, id)
+			return nil
+		}
+		if createsCycle(parents, id, parent) {
+			return ErrCycle
+		}
+		parents[id] = parent
+		return nil
+	})
+}
+
+// Remove удаляет категорию из иерархии, поднимая её детей к её родителю.
+func (s *ParentStore) Remove(ctx context.Context, id string) error {
+	return s.backend.update(ctx, func(parents map[string]string) error {
+		grandparent := parents[id]
+		for child, parent := range parents {
+			if parent != id {
+				continue
+			}
+			if grandparent == "" {
+				delete(parents, child)
+			} else {
+				parents[child] = grandparent
+			}
+		}
+		delete(parents, id)
+		return nil
+	})
+}
+
+// fileParents хранит связи в памяти и, если задан path, в JSON-файле.
+type fileParents struct {
+	path    string
+	mu      sync.RWMutex
+	parents map[string]string
+}
+
+func (b *fileParents) load(context.Context) (map[string]string, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return copyParents(b.parents), nil
+}
+
+func (b *fileParents) update(_ context.Context, fn func(map[string]string) error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	next := copyParents(b.parents)
+	if err := fn(next); err != nil {
+		return err
+	}
+	if err := b.save(next); err != nil {
+		return err
+	}
+	b.parents = next
+	return nil
+}
+
+// save атомарно записывает связи в файл. Вызывается под b.mu.
+func (b *fileParents) save(parents map[string]string) error {
+	if b.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(parents)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(b.path), 0o755); err != nil {
+		return err
+	}
+	tmp := b.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, b.path)
+}
+
+// redisParents хранит связи JSON-объектом в одном ключе Redis. Изменения
+// идут через WATCH/MULTI: если ключ поменяла другая реплика, попытка
+// повторяется на свежих данных.
+type redisParents struct {
+	client redis.UniversalClient
+	key    string
+}
+
+// maxUpdateAttempts — сколько раз update повторяет транзакцию при гонке.
+const maxUpdateAttempts = 10
+
+func (b *redisParents) load(ctx context.Context) (map[string]string, error) {
+	return b.get(ctx, b.client)
+}
+
+func (b *redisParents) get(ctx context.Context, c redis.Cmdable) (map[string]string, error) {
+	parents := make(map[string]string)
+	data, err := c.Get(ctx, b.key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return parents, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &parents); err != nil {
+		return nil, err
+	}
+	return parents, nil
+}
+
+func (b *redisParents) update(ctx context.Context, fn func(map[string]string) error) error {
+	for i := 0; i < maxUpdateAttempts; i++ {
+		err := b.client.Watch(ctx, func(tx *redis.Tx) error {
+			parents, err := b.get(ctx, tx)
+			if err != nil {
+				return err
+			}
+			if err := fn(parents); err != nil {
+				return err
+			}
+			data, err := json.Marshal(parents)
+			if err != nil {
+				return err
+			}
+			_, err = tx.TxPipelined(ctx, func(p redis.Pipeliner) error {
+				return p.Set(ctx, b.key, data, 0).Err()
+			})
+			return err
+		}, b.key)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+	}
+	return redis.TxFailedErr
+}
+
+func copyParents(parents map[string]string) map[string]string {
+	out := make(map[string]string, len(parents))
+	for k, v := range parents {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,311 @@
+// internal/category/parents_test.go
+package category
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// fakeRedis — сервер протокола Redis (RESP2) со строковыми ключами и
+// транзакциями: GET, SET, WATCH, UNWATCH, MULTI, EXEC и DISCARD. На остальные
+// команды, в том числе HELLO, отвечает ошибкой, и go-redis остаётся на RESP2.
+type fakeRedis struct {
+	ln       net.Listener
+	mu       sync.Mutex
+	values   map[string]string
+	versions map[string]int
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &fakeRedis{ln: ln, values: make(map[string]string), versions: make(map[string]int)}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			nc, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(nc)
+		}
+	}()
+	return s
+}
+
+func (s *fakeRedis) client(t *testing.T) *redis.Client {
+	c := redis.NewClient(&redis.Options{Addr: s.ln.Addr().String()})
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func bulk(s string) string {
+	return "$" + strconv.Itoa(len(s)) + "\r\n" + s + "\r\n"
+}
+
+func (s *fakeRedis) serve(nc net.Conn) {
+	defer nc.Close()
+	r := bufio.NewReader(nc)
+	w := bufio.NewWriter(nc)
+	watched := map[string]int{}
+	var queue [][]string
+	inMulti := false
+
+	// exec выполняет команду над данными; вызывается под s.mu.
+	exec := func(args []string) string {
+		switch strings.ToUpper(args[0]) {
+		case "GET":
+			v, ok := s.values[args[1]]
+			if !ok {
+				return "$-1\r\n"
+			}
+			return bulk(v)
+		case "SET":
+			s.values[args[1]] = args[2]
+			s.versions[args[1]]++
+			return "+OK\r\n"
+		}
+		return "-ERR unknown command '" + args[0] + "'\r\n"
+	}
+
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(args[0])
+		switch {
+		case cmd == "MULTI":
+			inMulti, queue = true, nil
+			w.WriteString("+OK\r\n")
+		case cmd == "DISCARD":
+			inMulti, queue, watched = false, nil, map[string]int{}
+			w.WriteString("+OK\r\n")
+		case cmd == "EXEC":
+			s.mu.Lock()
+			aborted := false
+			for key, version := range watched {
+				aborted = aborted || s.versions[key] != version
+			}
+			if aborted {
+				w.WriteString("*-1\r\n")
+			} else {
+				w.WriteString("*" + strconv.Itoa(len(queue)) + "\r\n")
+				for _, q := range queue {
+					w.WriteString(exec(q))
+				}
+			}
+			s.mu.Unlock()
+			inMulti, queue, watched = false, nil, map[string]int{}
+		case inMulti:
+			queue = append(queue, args)
+			w.WriteString("+QUEUED\r\n")
+		case cmd == "WATCH":
+			s.mu.Lock()
+			for _, key := range args[1:] {
+				watched[key] = s.versions[key]
+			}
+			s.mu.Unlock()
+			w.WriteString("+OK\r\n")
+		case cmd == "UNWATCH":
+			watched = map[string]int{}
+			w.WriteString("+OK\r\n")
+		case cmd == "PING":
+			w.WriteString("+PONG\r\n")
+		default:
+			s.mu.Lock()
+			w.WriteString(exec(args))
+			s.mu.Unlock()
+		}
+		w.Flush()
+	}
+}
+
+// readCommand читает команду — массив bulk-строк.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, fmt.Errorf("unexpected %q", line)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("bad array header %q", line)
+	}
+	args := make([]string, n)
+	for i := range args {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, fmt.Errorf("bad bulk header %q", line)
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+// parentStores возвращает хранилища обоих видов для одних и тех же тестов.
+func parentStores(t *testing.T) map[string]*ParentStore {
+	memory, err := NewParentStore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]*ParentStore{
+		"memory": memory,
+		"redis":  NewRedisParentStore(newFakeRedis(t).client(t), "category-parents"),
+	}
+}
+
+func TestCreatesCycle(t *testing.T) {
+	// a ← b ← c ← d: a корень
+	parents := map[string]string{"b": "a", "c": "b", "d": "c"}
+	tests := []struct {
+		id, parent string
+		want       bool
+	}{
+		{"a", "a", true},
+		{"a", "d", true},
+		{"b", "c", true},
+		{"c", "a", false},
+		{"d", "a", false},
+		{"a", "e", false},
+		{"e", "d", false},
+	}
+	for _, tt := range tests {
+		if got := createsCycle(parents, tt.id, tt.parent); got != tt.want {
+			t.Errorf("createsCycle(%s under %s) = %v, want %v", tt.id, tt.parent, got, tt.want)
+		}
+	}
+	// уже испорченные данные не зацикливают проверку
+	if !createsCycle(map[string]string{"x": "y", "y": "x"}, "z", "x") {
+		t.Error("existing cycle is not reported")
+	}
+}
+
+func TestParentStore(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range parentStores(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, link := range [][2]string{{"b", "a"}, {"c", "b"}, {"d", "c"}, {"e", "b"}} {
+				if err := s.SetParent(ctx, link[0], link[1]); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, bad := range [][2]string{{"a", "a"}, {"a", "d"}, {"b", "e"}} {
+				if err := s.SetParent(ctx, bad[0], bad[1]); !errors.Is(err, ErrCycle) {
+					t.Errorf("%s under %s: got %v, want ErrCycle", bad[0], bad[1], err)
+				}
+			}
+			if p, err := s.Parent(ctx, "d"); err != nil || p != "c" {
+				t.Fatalf("parent of d = %q, %v", p, err)
+			}
+
+			// дети удалённой категории поднимаются к её родителю
+			if err := s.Remove(ctx, "b"); err != nil {
+				t.Fatal(err)
+			}
+			want := map[string]string{"c": "a", "d": "c", "e": "a"}
+			if got, err := s.Snapshot(ctx); err != nil || !reflect.DeepEqual(got, want) {
+				t.Fatalf("after removing b: %v, %v, want %v", got, err, want)
+			}
+			if err := s.SetParent(ctx, "c", ""); err != nil {
+				t.Fatal(err)
+			}
+			if p, _ := s.Parent(ctx, "c"); p != "" {
+				t.Fatalf("c moved to the root has parent %q", p)
+			}
+
+			// снимок — копия, а не внутренняя карта
+			snapshot, _ := s.Snapshot(ctx)
+			snapshot["x"] = "y"
+			if p, _ := s.Parent(ctx, "x"); p != "" {
+				t.Fatal("snapshot shares memory with the store")
+			}
+		})
+	}
+}
+
+func TestParentStoreFile(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "nested", "parents.json")
+	s, err := NewParentStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetParent(ctx, "b", "a"); err != nil {
+		t.Fatal(err)
+	}
+	// неудачный перенос не меняет ни память, ни файл
+	if err := s.SetParent(ctx, "a", "b"); !errors.Is(err, ErrCycle) {
+		t.Fatalf("got %v, want ErrCycle", err)
+	}
+	reloaded, err := NewParentStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := reloaded.Snapshot(ctx); !reflect.DeepEqual(got, map[string]string{"b": "a"}) {
+		t.Fatalf("reloaded %v", got)
+	}
+}
+
+// TestRedisParentStoreReplicas — две реплики одновременно переносят a под b
+// и b под a. Вместе переносы дали бы цикл, поэтому успешен ровно один.
+func TestRedisParentStoreReplicas(t *testing.T) {
+	ctx := context.Background()
+	srv := newFakeRedis(t)
+	for i := 0; i < 20; i++ {
+		key := "parents-" + strconv.Itoa(i)
+		replicas := []*ParentStore{
+			NewRedisParentStore(srv.client(t), key),
+			NewRedisParentStore(srv.client(t), key),
+		}
+		var wg sync.WaitGroup
+		errs := make([]error, 2)
+		for j, move := range [][2]string{{"a", "b"}, {"b", "a"}} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[j] = replicas[j].SetParent(ctx, move[0], move[1])
+			}()
+		}
+		wg.Wait()
+		if (errs[0] == nil) == (errs[1] == nil) {
+			t.Fatalf("round %d: got %v and %v, want exactly one ErrCycle", i, errs[0], errs[1])
+		}
+		for _, err := range errs {
+			if err != nil && !errors.Is(err, ErrCycle) {
+				t.Fatal(err)
+			}
+		}
+		// вторая реплика видит перенос, сделанный первой
+		a, _ := replicas[1].Snapshot(ctx)
+		b, _ := replicas[0].Snapshot(ctx)
+		if len(a) != 1 || !reflect.DeepEqual(a, b) {
+			t.Fatalf("round %d: replicas see %v and %v", i, a, b)
+		}
+	}
+}
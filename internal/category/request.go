@@ -3,9 +3,28 @@ package category
 type createCategoryRequest struct {
 	Name        string `json:"name" binding:"required"`
 	Description string `json:"description" binding:"required"`
+	ParentId    string `json:"parent_id,omitempty" binding:"omitempty,uuid"`
 }
 
+// updateCategoryRequest — полная замена категории (PUT). Без parent_id
+// категория становится корневой.
 type updateCategoryRequest struct {
-	Name        string `json:"name,omitempty" binding:"omitempty,min=1"`
-	Description string `json:"description,omitempty" binding:"omitempty,min=1"`
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description" binding:"required"`
+	ParentId    string `json:"parent_id,omitempty" binding:"omitempty,uuid"`
+}
+
+// patchCategoryRequest — частичное изменение категории (PATCH, JSON Merge Patch).
+type patchCategoryRequest struct {
+	Name        *string `json:"name" binding:"omitempty,min=1"`
+	Description *string `json:"description" binding:"omitempty,min=1"`
+	ParentId    *string `json:"parent_id" binding:"omitempty,uuid"`
+}
+
+// categoryFields — поля категории, которые можно передавать в маске изменений.
+var categoryFields = []string{"name", "description"}
+
+type deleteCategoryRequest struct {
+	Force      bool   `form:"force"`
+	ReassignTo string `form:"reassign_to" binding:"omitempty,uuid"`
 }
@@ -10,7 +10,22 @@ type Response struct {
 
 type CategoryResponse struct {
 	Response
-	Category *commonpbv1.CategoryData `json:"category,omitempty"`
+	Category    *commonpbv1.CategoryData `json:"category,omitempty"`
+	ParentId    string                   `json:"parent_id,omitempty"`
+	Breadcrumbs []Breadcrumb             `json:"breadcrumbs,omitempty"`
+}
+
+// ReassignResponse — ответ на удаление категории, когда перенести удалось
+// не все её заказы.
+type ReassignResponse struct {
+	Response
+	Moved  []string `json:"moved"`
+	Failed []string `json:"failed"`
+}
+
+type CategoryTreeResponse struct {
+	Response
+	Categories []*CategoryNode `json:"categories"`
 }
 
 type CategoriesResponse struct {
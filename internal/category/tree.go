@@ -0,0 +1,86 @@
+package category
+
+import (
+	"sort"
+
+	commonpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/common/v1"
+)
+
+// createsCycle проверяет, встречается ли id среди предков parent (включая сам parent).
+func createsCycle(parents map[string]string, id, parent string) bool {
+	seen := make(map[string]bool)
+	for cur := parent; cur != ""; cur = parents[cur] {
+		if cur == id || seen[cur] {
+			return true
+		}
+		seen[cur] = true
+	}
+	return false
+}
+
+// CategoryNode — узел дерева категорий.
+type CategoryNode struct {
+	*commonpbv1.CategoryData
+	ParentId string          `json:"parent_id,omitempty"`
+	Children []*CategoryNode `json:"children"`
+}
+
+// Breadcrumb — элемент пути от корня до категории.
+type Breadcrumb struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// buildTree собирает дерево из плоского списка. Категории, чей родитель
+// не найден среди categories или чьи предки замкнуты в цикл, становятся
+// корневыми.
+func buildTree(categories []*commonpbv1.CategoryData, parents map[string]string) []*CategoryNode {
+	nodes := make(map[string]*CategoryNode, len(categories))
+	for _, c := range categories {
+		nodes[c.GetId()] = &CategoryNode{CategoryData: c, Children: []*CategoryNode{}}
+	}
+
+	roots := []*CategoryNode{}
+	for _, c := range categories {
+		node := nodes[c.GetId()]
+		if p, ok := nodes[parents[c.GetId()]]; ok && !createsCycle(parents, c.GetId(), p.GetId()) {
+			node.ParentId = p.GetId()
+			p.Children = append(p.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+
+	var sortNodes func([]*CategoryNode)
+	sortNodes = func(list []*CategoryNode) {
+		sort.SliceStable(list, func(i, j int) bool { return list[i].GetName() < list[j].GetName() })
+		for _, n := range list {
+			sortNodes(n.Children)
+		}
+	}
+	sortNodes(roots)
+	return roots
+}
+
+// breadcrumbs возвращает путь от корня до категории id включительно.
+func breadcrumbs(id string, categories []*commonpbv1.CategoryData, parents map[string]string) []Breadcrumb {
+	byId := make(map[string]*commonpbv1.CategoryData, len(categories))
+	for _, c := range categories {
+		byId[c.GetId()] = c
+	}
+
+	var path []Breadcrumb
+	seen := make(map[string]bool)
+	for cur := id; cur != "" && !seen[cur]; cur = parents[cur] {
+		seen[cur] = true
+		c, ok := byId[cur]
+		if !ok {
+			break
+		}
+		path = append(path, Breadcrumb{Id: c.GetId(), Name: c.GetName()})
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
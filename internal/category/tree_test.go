@@ -0,0 +1,80 @@
+// internal/category/tree_test.go
+package category
+
+import (
+	"reflect"
+	"testing"
+
+	commonpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/common/v1"
+)
+
+func categoryList(names ...string) []*commonpbv1.CategoryData {
+	list := make([]*commonpbv1.CategoryData, len(names))
+	for i, name := range names {
+		list[i] = &commonpbv1.CategoryData{Id: name, Name: name}
+	}
+	return list
+}
+
+// shape описывает дерево строкой вида "a(b(c) d) e".
+func shape(nodes []*CategoryNode) string {
+	s := ""
+	for i, n := range nodes {
+		if i > 0 {
+			s += " "
+		}
+		s += n.GetId()
+		if len(n.Children) > 0 {
+			s += "(" + shape(n.Children) + ")"
+		}
+	}
+	return s
+}
+
+func TestBuildTree(t *testing.T) {
+	categories := categoryList("e", "d", "c", "b", "a", "x", "y")
+	tests := []struct {
+		name    string
+		parents map[string]string
+		want    string
+	}{
+		{"flat", nil, "a b c d e x y"},
+		{"deep chain", map[string]string{"b": "a", "c": "b", "d": "c", "e": "d"}, "a(b(c(d(e)))) x y"},
+		{"siblings are sorted", map[string]string{"e": "a", "c": "a", "d": "a"}, "a(c d e) b x y"},
+		{"unknown parent", map[string]string{"b": "gone"}, "a b c d e x y"},
+		{"cycle and its descendants become roots", map[string]string{"x": "y", "y": "x", "b": "x", "c": "b"}, "a b c d e x y"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shape(buildTree(categories, tt.parents)); got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBreadcrumbs(t *testing.T) {
+	categories := categoryList("a", "b", "c", "d", "x", "y")
+	parents := map[string]string{"b": "a", "c": "b", "d": "c", "x": "y", "y": "x"}
+	ids := func(path []Breadcrumb) []string {
+		out := []string{}
+		for _, b := range path {
+			out = append(out, b.Id)
+		}
+		return out
+	}
+	tests := []struct {
+		id   string
+		want []string
+	}{
+		{"a", []string{"a"}},
+		{"d", []string{"a", "b", "c", "d"}},
+		{"x", []string{"y", "x"}},
+		{"missing", []string{}},
+	}
+	for _, tt := range tests {
+		if got := ids(breadcrumbs(tt.id, categories, parents)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("breadcrumbs(%s) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
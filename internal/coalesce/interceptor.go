@@ -0,0 +1,127 @@
+// internal/coalesce/interceptor.go
+package coalesce
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"expvar"
+	"sort"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// defaultTimeout ограничивает общий вызов, если у инициатора нет дедлайна.
+const defaultTimeout = 10 * time.Second
+
+// stats — счётчики по методам: calls — реальные вызовы сервиса,
+// coalesced — запросы, получившие ответ чужого вызова. Доступны через expvar.
+var stats = expvar.NewMap("grpc_coalesce")
+
+// UnaryClientInterceptor склеивает одинаковые одновременные вызовы методов
+// methods: пока первый вызов не завершён, остальные с тем же методом, телом
+// запроса и исходящими метаданными ждут его ответ. Подходит только для чтений.
+func UnaryClientInterceptor(methods ...string) grpc.UnaryClientInterceptor {
+	allowed := make(map[string]bool, len(methods))
+	for _, m := range methods {
+		allowed[m] = true
+	}
+	var group singleflight.Group
+
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if !allowed[method] {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		reqMsg, ok1 := req.(proto.Message)
+		replyMsg, ok2 := reply.(proto.Message)
+		if !ok1 || !ok2 {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		key, err := callKey(ctx, method, reqMsg)
+		if err != nil {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		// Result.Shared истинно и у того, чья функция выполнила вызов, поэтому
+		// склеенные запросы отличаем по метке вызова, которую функция кладёт
+		// в результат: совпадает она только у запроса, запустившего вызов.
+		token := new(byte)
+		ch := group.DoChan(key, func() (any, error) {
+			stats.Add(method+".calls", 1)
+			// общий вызов не должен обрываться, если инициатор отменил свой запрос
+			callCtx, cancel := sharedContext(ctx)
+			defer cancel()
+			out := replyMsg.ProtoReflect().New().Interface()
+			if err := invoker(callCtx, method, req, out, cc, opts...); err != nil {
+				return callResult{leader: token}, err
+			}
+			return callResult{leader: token, reply: out}, nil
+		})
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case res := <-ch:
+			result := res.Val.(callResult)
+			if result.leader != token {
+				stats.Add(method+".coalesced", 1)
+			}
+			if res.Err != nil {
+				return res.Err
+			}
+			// ответ общий для всех ожидающих, поэтому каждому отдаём копию
+			proto.Reset(replyMsg)
+			proto.Merge(replyMsg, result.reply)
+			return nil
+		}
+	}
+}
+
+// callResult — результат общего вызова и метка запроса, который его запустил.
+type callResult struct {
+	leader *byte
+	reply  proto.Message
+}
+
+// callKey строит ключ вызова из метода, тела запроса и исходящих метаданных
+// (в них, например, маска полей), чтобы не склеить разные по смыслу запросы.
+func callKey(ctx context.Context, method string, req proto.Message) (string, error) {
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write(b)
+	if md, ok := metadata.FromOutgoingContext(ctx); ok {
+		keys := make([]string, 0, len(md))
+		for k := range md {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			for _, v := range md[k] {
+				h.Write([]byte{0})
+				h.Write([]byte(k))
+				h.Write([]byte{'='})
+				h.Write([]byte(v))
+			}
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// sharedContext отвязывает вызов от отмены инициатора, сохраняя его значения
+// и дедлайн (или defaultTimeout, если дедлайна нет).
+func sharedContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultTimeout)
+	}
+	return context.WithDeadline(context.WithoutCancel(ctx), deadline)
+}
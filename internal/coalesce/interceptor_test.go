@@ -0,0 +1,188 @@
+// internal/coalesce/interceptor_test.go
+package coalesce
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const (
+	readMethod  = "/test.Service/Get"
+	writeMethod = "/test.Service/Update"
+)
+
+// countingInvoker отвечает "reply:<запрос>" и считает вызовы. Пока открыт
+// hold, вызовы ждут его закрытия.
+type countingInvoker struct {
+	calls atomic.Int32
+	hold  chan struct{}
+	err   error
+}
+
+func (f *countingInvoker) invoke(ctx context.Context, _ string, req, reply any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+	f.calls.Add(1)
+	if f.hold != nil {
+		select {
+		case <-f.hold:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if f.err != nil {
+		return f.err
+	}
+	reply.(*wrapperspb.StringValue).Value = "reply:" + req.(*wrapperspb.StringValue).GetValue()
+	return nil
+}
+
+// counter возвращает значение счётчика expvar для method.
+func counter(method, name string) int64 {
+	v, ok := stats.Get(method + "." + name).(*expvar.Int)
+	if !ok {
+		return 0
+	}
+	return v.Value()
+}
+
+// callAll выполняет n одинаковых вызовов одновременно и возвращает ответы
+// и ошибки. Вызовы отпускаются, когда все уже ждут ответа.
+func callAll(t *testing.T, interceptor grpc.UnaryClientInterceptor, f *countingInvoker, ctx context.Context, method string, n int) ([]*wrapperspb.StringValue, []error) {
+	t.Helper()
+	replies := make([]*wrapperspb.StringValue, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range replies {
+		replies[i] = &wrapperspb.StringValue{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = interceptor(ctx, method, wrapperspb.String("q"), replies[i], nil, f.invoke)
+		}()
+	}
+	// у singleflight нет способа узнать число ожидающих, поэтому даём
+	// горутинам время встать в очередь за первым вызовом
+	time.Sleep(50 * time.Millisecond)
+	close(f.hold)
+	wg.Wait()
+	return replies, errs
+}
+
+func TestCoalescesConcurrentCalls(t *testing.T) {
+	const n = 10
+	interceptor := UnaryClientInterceptor(readMethod)
+	f := &countingInvoker{hold: make(chan struct{})}
+	calls, coalesced := counter(readMethod, "calls"), counter(readMethod, "coalesced")
+
+	replies, errs := callAll(t, interceptor, f, context.Background(), readMethod, n)
+	for i := range replies {
+		if errs[i] != nil || replies[i].GetValue() != "reply:q" {
+			t.Fatalf("call %d: %v %q", i, errs[i], replies[i].GetValue())
+		}
+	}
+	if got := f.calls.Load(); got != 1 {
+		t.Fatalf("service called %d times, want 1", got)
+	}
+	if got := counter(readMethod, "calls") - calls; got != 1 {
+		t.Errorf("calls counter moved by %d, want 1", got)
+	}
+	if got := counter(readMethod, "coalesced") - coalesced; got != n-1 {
+		t.Errorf("coalesced counter moved by %d, want %d", got, n-1)
+	}
+	// каждый получил свою копию ответа
+	replies[0].Value = "changed"
+	if replies[1].GetValue() != "reply:q" {
+		t.Fatal("replies share one message")
+	}
+}
+
+func TestCoalescedErrorIsShared(t *testing.T) {
+	interceptor := UnaryClientInterceptor(readMethod)
+	f := &countingInvoker{hold: make(chan struct{}), err: errors.New("unavailable")}
+	_, errs := callAll(t, interceptor, f, context.Background(), readMethod, 3)
+	for i, err := range errs {
+		if err == nil || err.Error() != "unavailable" {
+			t.Fatalf("call %d: %v", i, err)
+		}
+	}
+	if got := f.calls.Load(); got != 1 {
+		t.Fatalf("service called %d times, want 1", got)
+	}
+}
+
+func TestDoesNotCoalesce(t *testing.T) {
+	interceptor := UnaryClientInterceptor(readMethod)
+	tests := []struct {
+		name   string
+		method string
+		ctx    func(i int) context.Context
+	}{
+		{"method is not listed", writeMethod, func(int) context.Context { return context.Background() }},
+		{"different metadata", readMethod, func(i int) context.Context {
+			return metadata.AppendToOutgoingContext(context.Background(), "authorization", string(rune('a'+i)))
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &countingInvoker{hold: make(chan struct{})}
+			var wg sync.WaitGroup
+			for i := 0; i < 3; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					reply := &wrapperspb.StringValue{}
+					if err := interceptor(tt.ctx(i), tt.method, wrapperspb.String("q"), reply, nil, f.invoke); err != nil {
+						t.Error(err)
+					}
+				}()
+			}
+			time.Sleep(50 * time.Millisecond)
+			close(f.hold)
+			wg.Wait()
+			if got := f.calls.Load(); got != 3 {
+				t.Fatalf("service called %d times, want 3", got)
+			}
+		})
+	}
+}
+
+func TestCancelledWaiterDoesNotCancelSharedCall(t *testing.T) {
+	interceptor := UnaryClientInterceptor(readMethod)
+	f := &countingInvoker{hold: make(chan struct{})}
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		first <- interceptor(ctx, readMethod, wrapperspb.String("q"), &wrapperspb.StringValue{}, nil, f.invoke)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	second := make(chan *wrapperspb.StringValue, 1)
+	go func() {
+		reply := &wrapperspb.StringValue{}
+		if err := interceptor(context.Background(), readMethod, wrapperspb.String("q"), reply, nil, f.invoke); err != nil {
+			t.Error(err)
+		}
+		second <- reply
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled caller: %v", err)
+	}
+	close(f.hold)
+	if got := <-second; !proto.Equal(got, wrapperspb.String("reply:q")) {
+		t.Fatalf("other caller got %v", got)
+	}
+	if got := f.calls.Load(); got != 1 {
+		t.Fatalf("service called %d times, want 1", got)
+	}
+}
@@ -0,0 +1,343 @@
+// internal/graphql/complexity.go
+package graphql
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	// maxComplexity — предельная оценочная стоимость запроса.
+	maxComplexity = 1000
+	// listFactor — во сколько раз список умножает стоимость вложенных полей.
+	listFactor = 10
+)
+
+// listFields — поля схемы, возвращающие списки.
+var listFields = map[string]bool{
+	"users":      true,
+	"orders":     true,
+	"myOrders":   true,
+	"categories": true,
+	"offers":     true,
+}
+
+var (
+	errTooComplex = errors.New("запрос слишком сложный")
+	// errComplexityUnknown — запрос не удалось разобрать для оценки. Такой
+	// запрос отклоняется: иначе сложность незаметно обходилась бы синтаксисом,
+	// который оценщик не понимает.
+	errComplexityUnknown = errors.New("не удалось оценить сложность запроса")
+)
+
+// selection — поле, фрагмент или inline-фрагмент в наборе полей.
+type selection struct {
+	field    string
+	spread   string
+	children []selection
+}
+
+// checkComplexity отклоняет запрос, стоимость которого больше maxComplexity
+// или не поддаётся оценке.
+func checkComplexity(query string) error {
+	c, ok := complexity(query)
+	if !ok {
+		return errComplexityUnknown
+	}
+	if c > maxComplexity {
+		return errTooComplex
+	}
+	return nil
+}
+
+// complexity оценивает стоимость запроса: каждое поле стоит 1 плюс стоимость
+// вложенных полей, а поля-списки умножают вложенную стоимость на listFactor.
+// Оценка выше maxComplexity не уточняется. false означает, что запрос не
+// удалось разобрать.
+func complexity(query string) (int, bool) {
+	p := &parser{tokens: tokenize(query)}
+	ops, fragments, ok := p.document()
+	if !ok || len(ops) == 0 {
+		return 0, false
+	}
+	e := &estimator{fragments: fragments, memo: map[string]int{}, visiting: map[string]bool{}}
+	total := 0
+	for _, op := range ops {
+		if c := e.cost(op); c > total {
+			total = c
+		}
+	}
+	return total, true
+}
+
+// estimator считает стоимость наборов полей. Стоимость фрагмента не зависит
+// от места, куда он подставлен, поэтому считается один раз: иначе цепочка
+// фрагментов, каждый из которых дважды подставляет предыдущий, стоила бы
+// оценщику 2^n шагов.
+type estimator struct {
+	fragments map[string][]selection
+	memo      map[string]int
+	visiting  map[string]bool
+}
+
+func (e *estimator) cost(set []selection) int {
+	total := 0
+	for _, s := range set {
+		switch {
+		case s.spread != "":
+			total += e.fragment(s.spread)
+		case s.field == "":
+			total += e.cost(s.children)
+		default:
+			nested := e.cost(s.children)
+			if listFields[s.field] {
+				nested *= listFactor
+			}
+			total += 1 + nested
+		}
+		// дальше считать незачем: запрос уже отклонён
+		if total > maxComplexity {
+			return maxComplexity + 1
+		}
+	}
+	return total
+}
+
+func (e *estimator) fragment(name string) int {
+	if c, ok := e.memo[name]; ok {
+		return c
+	}
+	// цикл фрагментов ничего не добавляет: такой запрос отклонит валидация
+	if e.visiting[name] {
+		return 0
+	}
+	e.visiting[name] = true
+	c := e.cost(e.fragments[name])
+	delete(e.visiting, name)
+	e.memo[name] = c
+	return c
+}
+
+// tokenize разбивает запрос на имена и знаки пунктуации, пропуская
+// пробелы, запятые, комментарии, строки и числа.
+func tokenize(src string) []string {
+	src = strings.TrimPrefix(src, "\ufeff")
+	var tokens []string
+	for i := 0; i < len(src); {
+		ch := src[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' || ch == ',':
+			i++
+		case ch == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case ch == '"':
+			if strings.HasPrefix(src[i:], `"""`) {
+				end := strings.Index(src[i+3:], `"""`)
+				if end < 0 {
+					// незакрытая строка: остаток не разобрать
+					return append(tokens, "\"")
+				}
+				i += 3 + end + 3
+				tokens = append(tokens, `""`)
+				continue
+			}
+			i++
+			for i < len(src) && src[i] != '"' {
+				if src[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			i++
+			tokens = append(tokens, `""`)
+		case strings.HasPrefix(src[i:], "..."):
+			tokens = append(tokens, "...")
+			i += 3
+		case isNameStart(ch):
+			j := i + 1
+			for j < len(src) && (isNameStart(src[j]) || src[j] >= '0' && src[j] <= '9') {
+				j++
+			}
+			tokens = append(tokens, src[i:j])
+			i = j
+		case ch == '-' || ch >= '0' && ch <= '9':
+			j := i + 1
+			for j < len(src) && strings.IndexByte("0123456789.eE+-", src[j]) >= 0 {
+				j++
+			}
+			tokens = append(tokens, "0")
+			i = j
+		default:
+			tokens = append(tokens, string(ch))
+			i++
+		}
+	}
+	return tokens
+}
+
+func isNameStart(ch byte) bool {
+	return ch == '_' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z'
+}
+
+// maxParseDepth — предельная вложенность наборов полей, которую разбирает
+// оценщик. Исполнение всё равно ограничено maxDepth, а здесь предел не даёт
+// раскрутить рекурсию парсера строкой из одних скобок.
+const maxParseDepth = 64
+
+// parser разбирает только то, что нужно для оценки: операции, фрагменты
+// и наборы полей. Аргументы, переменные и директивы пропускаются.
+type parser struct {
+	tokens []string
+	pos    int
+	depth  int
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *parser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *parser) document() ([][]selection, map[string][]selection, bool) {
+	var ops [][]selection
+	fragments := make(map[string][]selection)
+	for p.pos < len(p.tokens) {
+		switch p.peek() {
+		case "{":
+			set, ok := p.selectionSet()
+			if !ok {
+				return nil, nil, false
+			}
+			ops = append(ops, set)
+		case "query", "mutation", "subscription":
+			p.next()
+			for p.peek() != "{" {
+				if p.peek() == "" {
+					return nil, nil, false
+				}
+				if p.peek() == "(" {
+					p.skipBalanced("(", ")")
+					continue
+				}
+				p.next()
+			}
+			set, ok := p.selectionSet()
+			if !ok {
+				return nil, nil, false
+			}
+			ops = append(ops, set)
+		case "fragment":
+			p.next()
+			name := p.next()
+			for p.peek() != "{" {
+				if p.peek() == "" {
+					return nil, nil, false
+				}
+				if p.peek() == "(" {
+					p.skipBalanced("(", ")")
+					continue
+				}
+				p.next()
+			}
+			set, ok := p.selectionSet()
+			if !ok {
+				return nil, nil, false
+			}
+			fragments[name] = set
+		default:
+			return nil, nil, false
+		}
+	}
+	return ops, fragments, true
+}
+
+func (p *parser) selectionSet() ([]selection, bool) {
+	if p.next() != "{" || p.depth >= maxParseDepth {
+		return nil, false
+	}
+	p.depth++
+	defer func() { p.depth-- }()
+	var set []selection
+	for {
+		t := p.peek()
+		switch {
+		case t == "":
+			return nil, false
+		case t == "}":
+			p.next()
+			return set, true
+		case t == "...":
+			p.next()
+			if p.peek() == "on" || p.peek() == "@" || p.peek() == "{" {
+				if p.peek() == "on" {
+					p.next()
+					p.next()
+				}
+				p.skipDirectives()
+				children, ok := p.selectionSet()
+				if !ok {
+					return nil, false
+				}
+				set = append(set, selection{children: children})
+				continue
+			}
+			set = append(set, selection{spread: p.next()})
+			p.skipDirectives()
+		case isNameStart(t[0]):
+			name := p.next()
+			if p.peek() == ":" {
+				p.next()
+				name = p.next()
+			}
+			if p.peek() == "(" {
+				p.skipBalanced("(", ")")
+			}
+			p.skipDirectives()
+			s := selection{field: name}
+			if p.peek() == "{" {
+				children, ok := p.selectionSet()
+				if !ok {
+					return nil, false
+				}
+				s.children = children
+			}
+			set = append(set, s)
+		default:
+			return nil, false
+		}
+	}
+}
+
+func (p *parser) skipDirectives() {
+	for p.peek() == "@" {
+		p.next()
+		p.next()
+		if p.peek() == "(" {
+			p.skipBalanced("(", ")")
+		}
+	}
+}
+
+func (p *parser) skipBalanced(open, end string) {
+	depth := 0
+	for p.pos < len(p.tokens) {
+		switch p.next() {
+		case open:
+			depth++
+		case end:
+			depth--
+			if depth == 0 {
+				return
+			}
+		}
+	}
+}
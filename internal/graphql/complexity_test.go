@@ -0,0 +1,97 @@
+// internal/graphql/complexity_test.go
+package graphql
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestComplexity(t *testing.T) {
+	tests := []struct {
+		name, query string
+		want        int
+	}{
+		{"field", `{ me { id } }`, 2},
+		{"list multiplies nested fields", `{ users { id fio } }`, 21},
+		{"nested lists", `{ orders { id offers { id } } }`, 1 + 10*(1+1+10)},
+		{"alias counts the field, not the alias", `{ people: users { id } }`, 11},
+		{"alias does not hide a list", `{ users: me { id } }`, 2},
+		{"arguments are skipped", `{ order(id: "x", filter: {a: [1, 2], b: "}"}) { id } }`, 2},
+		{"directives are skipped", `{ me @include(if: true) { id @skip(if: false) } }`, 2},
+		{"named operation with variables", `query Q($id: ID!, $n: Int = 3) { order(id: $id) { id } }`, 2},
+		{"comments, commas and block strings", "{\n# users { id }\nme { id, fio(x: \"\"\"}{\"\"\") } }", 3},
+		{"fragment", `{ users { ...U } } fragment U on User { id fio }`, 21},
+		{"fragment used twice", `{ me { ...U } order(id: 1) { client { ...U } } } fragment U on User { id fio }`, 3 + 4},
+		{"inline fragment", `{ me { ... on User { id } } }`, 2},
+		{"inline fragment with directive", `{ me { ... @include(if: true) { id } } }`, 2},
+		{"largest operation wins", `query A { me { id } } query B { users { id } }`, 11},
+		{"fragment cycle", `{ me { ...A } } fragment A on User { id ...B } fragment B on User { fio ...A }`, 3},
+		{"capped above the limit", `{ users { orders { offers { categories { id } } } } }`, maxComplexity + 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := complexity(tt.query)
+			if !ok {
+				t.Fatalf("complexity(%q) could not parse", tt.query)
+			}
+			if got != tt.want {
+				t.Fatalf("complexity(%q) = %d, want %d", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComplexityMalformed(t *testing.T) {
+	for _, query := range []string{
+		``,
+		`   # only a comment`,
+		`{ me { id }`,
+		`{ me { id } } }`,
+		`query`,
+		`query Q($id: ID!`,
+		`fragment U on User`,
+		`{ me(id: "unterminated) { id } }`,
+		`{ me { id: } }`,
+		`{ "string" }`,
+		`subscription { offers { id } ` + strings.Repeat("}", 3),
+		strings.Repeat("{ me ", maxParseDepth+1) + strings.Repeat("}", maxParseDepth+1),
+	} {
+		if c, ok := complexity(query); ok {
+			t.Errorf("complexity(%q) = %d, want a parse failure", query, c)
+		}
+		if err := checkComplexity(query); err != errComplexityUnknown {
+			t.Errorf("checkComplexity(%q) = %v, want %v", query, err, errComplexityUnknown)
+		}
+	}
+}
+
+// TestComplexityFragmentChain — каждый фрагмент дважды подставляет
+// предыдущий: без запоминания оценка заняла бы 2^n шагов.
+func TestComplexityFragmentChain(t *testing.T) {
+	const n = 64
+	var b strings.Builder
+	b.WriteString("{ me { ...F0 } } fragment F0 on User { id }")
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&b, " fragment F%d on User { ...F%d ...F%d }", i, i-1, i-1)
+	}
+	// короткая цепочка ещё укладывается в лимит
+	short, ok := complexity(b.String())
+	if !ok || short != 2 {
+		t.Fatalf("complexity = %d, %v, want 2", short, ok)
+	}
+
+	query := strings.Replace(b.String(), "...F0 }", fmt.Sprintf("...F%d }", n), 1)
+	if err := checkComplexity(query); err != errTooComplex {
+		t.Fatalf("checkComplexity = %v, want %v", err, errTooComplex)
+	}
+}
+
+func TestCheckComplexity(t *testing.T) {
+	if err := checkComplexity(`{ users { id } }`); err != nil {
+		t.Fatalf("simple query rejected: %v", err)
+	}
+	if err := checkComplexity(`{ users { orders { offers { id } } } }`); err != errTooComplex {
+		t.Fatalf("got %v, want %v", err, errTooComplex)
+	}
+}
@@ -0,0 +1,133 @@
+// internal/graphql/context.go
+package graphql
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/auth"
+	categorypbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/category/v1"
+	commonpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/common/v1"
+	offerpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/offer/v1"
+	orderpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/order/v1"
+	userv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/user/v1"
+	"github.com/Ostap00034/course-work-backend-auth-service/util/jwt"
+)
+
+// Clients — gRPC-клиенты сервисов, через которые резолвится схема.
+type Clients struct {
+	Users      userv1.UserServiceClient
+	Orders     orderpbv1.OrderServiceClient
+	Categories categorypbv1.CategoryServiceClient
+	Offers     offerpbv1.OfferServiceClient
+}
+
+type ctxKey int
+
+const (
+	claimsKey ctxKey = iota
+	loadersKey
+)
+
+// loaders — пакетные загрузчики одного запроса.
+type loaders struct {
+	users       *loader[*commonpbv1.UserData]
+	categories  *loader[*commonpbv1.CategoryData]
+	orderOffers *loader[[]*commonpbv1.OfferData]
+}
+
+var (
+	errUnauthorized  = errors.New("требуется авторизация")
+	errForbidden     = errors.New("доступ запрещён")
+	errOrderNotFound = errors.New("заказ не найден")
+)
+
+// requestContext готовит контекст запроса: metadata из auth.Middleware,
+// claims пользователя и свежие загрузчики.
+func requestContext(c *gin.Context, clients Clients) context.Context {
+	ctx := c.Request.Context()
+	if claims, ok := auth.CurrentUser(c); ok {
+		ctx = context.WithValue(ctx, claimsKey, claims)
+	}
+	return context.WithValue(ctx, loadersKey, newLoaders(ctx, clients))
+}
+
+func newLoaders(ctx context.Context, clients Clients) *loaders {
+	return &loaders{
+		users: newLoader(ctx, fetchEach(func(ctx context.Context, id string) (*commonpbv1.UserData, error) {
+			resp, err := clients.Users.GetUserById(ctx, &userv1.GetUserByIdRequest{UserId: id})
+			if err != nil {
+				return nil, err
+			}
+			return resp.User, nil
+		})),
+		categories: newLoader(ctx, func(ctx context.Context, ids []string) ([]*commonpbv1.CategoryData, []error) {
+			return fetchCategories(ctx, clients.Categories, ids)
+		}),
+		orderOffers: newLoader(ctx, fetchEach(func(ctx context.Context, orderID string) ([]*commonpbv1.OfferData, error) {
+			resp, err := clients.Offers.GetMyOrderOffers(ctx, &offerpbv1.GetMyOrderOffersRequest{OrderId: orderID})
+			if err != nil {
+				return nil, err
+			}
+			return resp.Offers, nil
+		})),
+	}
+}
+
+// fetchCategories загружает одну категорию по id, а несколько — одним
+// GetCategories: список категорий небольшой и к тому же кэшируется сервисом.
+func fetchCategories(ctx context.Context, client categorypbv1.CategoryServiceClient, ids []string) ([]*commonpbv1.CategoryData, []error) {
+	vals := make([]*commonpbv1.CategoryData, len(ids))
+	errs := make([]error, len(ids))
+	if len(ids) == 1 {
+		resp, err := client.GetCategoryById(ctx, &categorypbv1.GetCategoryByIdRequest{Id: ids[0]})
+		if err != nil {
+			errs[0] = err
+		} else {
+			vals[0] = resp.Category
+		}
+		return vals, errs
+	}
+
+	resp, err := client.GetCategories(ctx, &categorypbv1.GetCategoriesRequest{})
+	if err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+		return vals, errs
+	}
+	byID := make(map[string]*commonpbv1.CategoryData, len(resp.Categories))
+	for _, c := range resp.Categories {
+		byID[c.GetId()] = c
+	}
+	for i, id := range ids {
+		vals[i] = byID[id]
+	}
+	return vals, errs
+}
+
+func claimsFrom(ctx context.Context) (*jwt.Claims, bool) {
+	claims, ok := ctx.Value(claimsKey).(*jwt.Claims)
+	return claims, ok
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey).(*loaders)
+}
+
+// rpcError переводит ошибку gRPC в ошибку GraphQL. NotFound для
+// необязательных полей превращается в null без ошибки.
+func rpcError(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return errors.New("внутренняя ошибка сервера")
+	}
+	if st.Code() == codes.NotFound {
+		return nil
+	}
+	return errors.New(st.Message())
+}
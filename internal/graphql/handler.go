@@ -0,0 +1,46 @@
+// internal/graphql/handler.go
+package graphql
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	qerrors "github.com/graph-gophers/graphql-go/errors"
+)
+
+// request — тело GraphQL-запроса по HTTP.
+type request struct {
+	Query         string                 `json:"query" binding:"required"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// errorsResponse — ответ в формате GraphQL с одной ошибкой.
+func errorsResponse(msg string) *graphqlgo.Response {
+	return &graphqlgo.Response{Errors: []*qerrors.QueryError{{Message: msg}}}
+}
+
+// QueryHandler выполняет запросы (query) по HTTP POST.
+func QueryHandler(schema *graphqlgo.Schema, clients Clients) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, errorsResponse("ожидается JSON с полем query"))
+			return
+		}
+		if err := checkComplexity(req.Query); err != nil {
+			c.JSON(http.StatusBadRequest, errorsResponse(err.Error()))
+			return
+		}
+		resp := schema.Exec(requestContext(c, clients), req.Query, req.OperationName, req.Variables)
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// RegisterHandlers вешает POST /graphql для запросов и GET /graphql для
+// WebSocket-подписок (протокол graphql-transport-ws).
+func RegisterHandlers(r gin.IRouter, schema *graphqlgo.Schema, clients Clients) {
+	r.POST("/graphql", QueryHandler(schema, clients))
+	r.GET("/graphql", SubscriptionHandler(schema, clients))
+}
@@ -0,0 +1,112 @@
+// internal/graphql/loader.go
+package graphql
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// batchWait — сколько loader копит ключи перед запросом к сервису.
+	batchWait = 2 * time.Millisecond
+	// maxBatch — после стольких ключей пакет отправляется сразу.
+	maxBatch = 100
+)
+
+// batchFunc загружает значения по ключам. Результаты и ошибки идут в том же
+// порядке, что и keys.
+type batchFunc[V any] func(ctx context.Context, keys []string) ([]V, []error)
+
+type loadResult[V any] struct {
+	done chan struct{}
+	val  V
+	err  error
+}
+
+type loadBatch[V any] struct {
+	once    sync.Once
+	keys    []string
+	results []*loadResult[V]
+}
+
+// loader собирает одновременные Load в один пакет и кэширует результаты
+// на время запроса, чтобы одна сущность не загружалась дважды.
+type loader[V any] struct {
+	ctx   context.Context
+	fetch batchFunc[V]
+
+	mu    sync.Mutex
+	cache map[string]*loadResult[V]
+	batch *loadBatch[V]
+}
+
+func newLoader[V any](ctx context.Context, fetch batchFunc[V]) *loader[V] {
+	return &loader[V]{ctx: ctx, fetch: fetch, cache: make(map[string]*loadResult[V])}
+}
+
+// Load возвращает значение по ключу.
+func (l *loader[V]) Load(ctx context.Context, key string) (V, error) {
+	l.mu.Lock()
+	r, ok := l.cache[key]
+	if !ok {
+		r = &loadResult[V]{done: make(chan struct{})}
+		l.cache[key] = r
+		if l.batch == nil {
+			b := &loadBatch[V]{}
+			l.batch = b
+			time.AfterFunc(batchWait, func() { l.dispatch(b) })
+		}
+		b := l.batch
+		b.keys = append(b.keys, key)
+		b.results = append(b.results, r)
+		if len(b.keys) >= maxBatch {
+			go l.dispatch(b)
+		}
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return r.val, r.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// dispatch отправляет пакет. Повторный вызов для того же пакета ничего не делает.
+func (l *loader[V]) dispatch(b *loadBatch[V]) {
+	b.once.Do(func() {
+		l.mu.Lock()
+		if l.batch == b {
+			l.batch = nil
+		}
+		l.mu.Unlock()
+
+		vals, errs := l.fetch(l.ctx, b.keys)
+		for i, r := range b.results {
+			r.val, r.err = vals[i], errs[i]
+			close(r.done)
+		}
+	})
+}
+
+// fetchEach загружает ключи параллельно по одному, когда у сервиса нет
+// пакетного метода.
+func fetchEach[V any](load func(ctx context.Context, key string) (V, error)) batchFunc[V] {
+	return func(ctx context.Context, keys []string) ([]V, []error) {
+		vals := make([]V, len(keys))
+		errs := make([]error, len(keys))
+		var wg sync.WaitGroup
+		for i, key := range keys {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				vals[i], errs[i] = load(ctx, key)
+			}()
+		}
+		wg.Wait()
+		return vals, errs
+	}
+}
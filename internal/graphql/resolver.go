@@ -0,0 +1,203 @@
+// internal/graphql/resolver.go
+package graphql
+
+import (
+	"context"
+	_ "embed"
+
+	"github.com/google/uuid"
+	graphqlgo "github.com/graph-gophers/graphql-go"
+
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/offer"
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/order"
+	categorypbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/category/v1"
+	orderpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/order/v1"
+	userv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/user/v1"
+)
+
+//go:embed schema.graphql
+var schemaSDL string
+
+const (
+	maxDepth       = 8
+	maxParallelism = 10
+	// subscriptionBuffer — сколько событий ждёт медленного подписчика,
+	// прежде чем начнут отбрасываться.
+	subscriptionBuffer = 16
+)
+
+// NewSchema собирает GraphQL-схему поверх gRPC-клиентов и хаба офферов.
+func NewSchema(clients Clients, hub *offer.Hub) (*graphqlgo.Schema, error) {
+	return graphqlgo.ParseSchema(schemaSDL, &resolver{clients: clients, hub: hub},
+		graphqlgo.MaxDepth(maxDepth),
+		graphqlgo.MaxParallelism(maxParallelism),
+	)
+}
+
+type resolver struct {
+	clients Clients
+	hub     *offer.Hub
+}
+
+func (r *resolver) Me(ctx context.Context) (*userResolver, error) {
+	claims, ok := claimsFrom(ctx)
+	if !ok {
+		return nil, errUnauthorized
+	}
+	return loadUser(ctx, claims.UserID)
+}
+
+func (r *resolver) User(ctx context.Context, args struct{ ID graphqlgo.ID }) (*userResolver, error) {
+	return loadUser(ctx, string(args.ID))
+}
+
+func (r *resolver) Users(ctx context.Context) ([]*userResolver, error) {
+	resp, err := r.clients.Users.GetUsers(ctx, &userv1.GetUsersRequest{})
+	if err != nil {
+		return nil, rpcError(err)
+	}
+	out := make([]*userResolver, 0, len(resp.Users))
+	for _, u := range resp.Users {
+		out = append(out, &userResolver{u})
+	}
+	return out, nil
+}
+
+func (r *resolver) Order(ctx context.Context, args struct{ ID graphqlgo.ID }) (*orderResolver, error) {
+	resp, err := r.clients.Orders.GetOrderById(ctx, &orderpbv1.GetOrderByIdRequest{Id: string(args.ID)})
+	if err != nil {
+		return nil, rpcError(err)
+	}
+	return &orderResolver{resp.Order}, nil
+}
+
+type ordersArgs struct {
+	Status      *string
+	CategoryIds *[]graphqlgo.ID
+	ClientID    *graphqlgo.ID
+	MasterID    *graphqlgo.ID
+}
+
+// Orders ищет заказы. Как и в GET /orders, отсутствующие clientId и masterId
+// передаются OrderService нулевым UUID — так он понимает «без фильтра».
+func (r *resolver) Orders(ctx context.Context, args ordersArgs) ([]*orderResolver, error) {
+	req := &orderpbv1.GetOrdersRequest{
+		Status:        deref(args.Status),
+		CategoriesIds: ids(args.CategoryIds),
+		ClientId:      uuid.Nil.String(),
+		MasterId:      uuid.Nil.String(),
+	}
+	if args.ClientID != nil {
+		req.ClientId = string(*args.ClientID)
+	}
+	if args.MasterID != nil {
+		req.MasterId = string(*args.MasterID)
+	}
+	resp, err := r.clients.Orders.GetOrders(ctx, req)
+	if err != nil {
+		if err := rpcError(err); err != nil {
+			return nil, err
+		}
+		return []*orderResolver{}, nil
+	}
+	return wrapOrders(resp.Orders), nil
+}
+
+type myOrdersArgs struct {
+	Status      *string
+	CategoryIds *[]graphqlgo.ID
+}
+
+func (r *resolver) MyOrders(ctx context.Context, args myOrdersArgs) ([]*orderResolver, error) {
+	claims, ok := claimsFrom(ctx)
+	if !ok {
+		return nil, errUnauthorized
+	}
+	resp, err := r.clients.Orders.GetMyOrders(ctx, &orderpbv1.GetMyOrdersRequest{
+		UserId:        claims.UserID,
+		Status:        deref(args.Status),
+		CategoriesIds: ids(args.CategoryIds),
+	})
+	if err != nil {
+		if err := rpcError(err); err != nil {
+			return nil, err
+		}
+		return []*orderResolver{}, nil
+	}
+	return wrapOrders(resp.Orders), nil
+}
+
+func (r *resolver) Category(ctx context.Context, args struct{ ID graphqlgo.ID }) (*categoryResolver, error) {
+	return loadCategory(ctx, string(args.ID))
+}
+
+func (r *resolver) Categories(ctx context.Context) ([]*categoryResolver, error) {
+	resp, err := r.clients.Categories.GetCategories(ctx, &categorypbv1.GetCategoriesRequest{})
+	if err != nil {
+		return nil, rpcError(err)
+	}
+	out := make([]*categoryResolver, 0, len(resp.Categories))
+	for _, c := range resp.Categories {
+		out = append(out, &categoryResolver{c})
+	}
+	return out, nil
+}
+
+// OfferUpdates пересылает события хаба офферов по заказу, пока клиент подписан.
+func (r *resolver) OfferUpdates(ctx context.Context, args struct{ OrderID graphqlgo.ID }) (<-chan *offerEventResolver, error) {
+	claims, ok := claimsFrom(ctx)
+	if !ok {
+		return nil, errUnauthorized
+	}
+	// следить за офферами могут те же, кто может подписаться по WebSocket
+	resp, err := r.clients.Orders.GetOrderById(ctx, &orderpbv1.GetOrderByIdRequest{Id: string(args.OrderID)})
+	if err != nil {
+		if err := rpcError(err); err != nil {
+			return nil, err
+		}
+		return nil, errOrderNotFound
+	}
+	if !order.CanFollow(resp.Order, claims.UserID, claims.Role) {
+		return nil, errForbidden
+	}
+	events, cancel := r.hub.Listen(string(args.OrderID), subscriptionBuffer)
+	out := make(chan *offerEventResolver)
+	go func() {
+		defer close(out)
+		defer cancel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-events:
+				if !ok {
+					return
+				}
+				select {
+				case out <- &offerEventResolver{ev}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+func deref(p *string) string {
+	if p == nil {
+		return ""
+	}
+	return *p
+}
+
+func ids(p *[]graphqlgo.ID) []string {
+	if p == nil {
+		return nil
+	}
+	out := make([]string, 0, len(*p))
+	for _, id := range *p {
+		out = append(out, string(id))
+	}
+	return out
+}
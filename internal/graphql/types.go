@@ -0,0 +1,139 @@
+// internal/graphql/types.go
+package graphql
+
+import (
+	"context"
+
+	graphqlgo "github.com/graph-gophers/graphql-go"
+
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/offer"
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/order"
+	commonpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/common/v1"
+)
+
+type userResolver struct{ u *commonpbv1.UserData }
+
+func (r *userResolver) ID() graphqlgo.ID  { return graphqlgo.ID(r.u.GetId()) }
+func (r *userResolver) Email() string     { return r.u.GetEmail() }
+func (r *userResolver) Fio() string       { return r.u.GetFio() }
+func (r *userResolver) Role() string      { return r.u.GetRole() }
+func (r *userResolver) CreatedAt() string { return r.u.GetCreatedAt() }
+func (r *userResolver) UpdatedAt() string { return r.u.GetUpdatedAt() }
+
+// loadUser загружает пользователя через loader запроса. Пустой id — null.
+func loadUser(ctx context.Context, id string) (*userResolver, error) {
+	if id == "" {
+		return nil, nil
+	}
+	u, err := loadersFrom(ctx).users.Load(ctx, id)
+	if err != nil {
+		return nil, rpcError(err)
+	}
+	if u == nil {
+		return nil, nil
+	}
+	return &userResolver{u}, nil
+}
+
+type categoryResolver struct{ c *commonpbv1.CategoryData }
+
+func (r *categoryResolver) ID() graphqlgo.ID    { return graphqlgo.ID(r.c.GetId()) }
+func (r *categoryResolver) Name() string        { return r.c.GetName() }
+func (r *categoryResolver) Description() string { return r.c.GetDescription() }
+func (r *categoryResolver) CreatedAt() string   { return r.c.GetCreatedAt() }
+func (r *categoryResolver) UpdatedAt() string   { return r.c.GetUpdatedAt() }
+
+func loadCategory(ctx context.Context, id string) (*categoryResolver, error) {
+	if id == "" {
+		return nil, nil
+	}
+	c, err := loadersFrom(ctx).categories.Load(ctx, id)
+	if err != nil {
+		return nil, rpcError(err)
+	}
+	if c == nil {
+		return nil, nil
+	}
+	return &categoryResolver{c}, nil
+}
+
+type orderResolver struct{ o *commonpbv1.OrderData }
+
+func (r *orderResolver) ID() graphqlgo.ID         { return graphqlgo.ID(r.o.GetId()) }
+func (r *orderResolver) Title() string            { return r.o.GetTitle() }
+func (r *orderResolver) Description() string      { return r.o.GetDescription() }
+func (r *orderResolver) Address() string          { return r.o.GetAddress() }
+func (r *orderResolver) Longitude() string        { return r.o.GetLongitude() }
+func (r *orderResolver) Latitude() string         { return r.o.GetLatitude() }
+func (r *orderResolver) Status() string           { return r.o.GetStatus() }
+func (r *orderResolver) Price() float64           { return float64(r.o.GetPrice()) }
+func (r *orderResolver) CategoryID() graphqlgo.ID { return graphqlgo.ID(r.o.GetCategoryId()) }
+func (r *orderResolver) CreatedAt() string        { return r.o.GetCreatedAt() }
+func (r *orderResolver) UpdatedAt() string        { return r.o.GetUpdatedAt() }
+
+func (r *orderResolver) Category(ctx context.Context) (*categoryResolver, error) {
+	return loadCategory(ctx, r.o.GetCategoryId())
+}
+
+func (r *orderResolver) Client(ctx context.Context) (*userResolver, error) {
+	return loadUser(ctx, r.o.GetClient().GetId())
+}
+
+func (r *orderResolver) Master(ctx context.Context) (*userResolver, error) {
+	return loadUser(ctx, r.o.GetMaster().GetId())
+}
+
+// Offers видят те же, кто может подписаться на офферы заказа: иначе любой
+// пользователь увидел бы чужие предложения по заказу.
+func (r *orderResolver) Offers(ctx context.Context) (*[]*offerResolver, error) {
+	claims, ok := claimsFrom(ctx)
+	if !ok {
+		return nil, errUnauthorized
+	}
+	if !order.CanFollow(r.o, claims.UserID, claims.Role) {
+		return nil, errForbidden
+	}
+	offers, err := loadersFrom(ctx).orderOffers.Load(ctx, r.o.GetId())
+	if err != nil {
+		if err := rpcError(err); err != nil {
+			return nil, err
+		}
+	}
+	out := make([]*offerResolver, 0, len(offers))
+	for _, o := range offers {
+		out = append(out, &offerResolver{o})
+	}
+	return &out, nil
+}
+
+func wrapOrders(orders []*commonpbv1.OrderData) []*orderResolver {
+	out := make([]*orderResolver, 0, len(orders))
+	for _, o := range orders {
+		out = append(out, &orderResolver{o})
+	}
+	return out
+}
+
+type offerResolver struct{ o *commonpbv1.OfferData }
+
+func (r *offerResolver) ID() graphqlgo.ID  { return graphqlgo.ID(r.o.GetId()) }
+func (r *offerResolver) Price() float64    { return float64(r.o.GetPrice()) }
+func (r *offerResolver) Status() string    { return r.o.GetStatus() }
+func (r *offerResolver) CreatedAt() string { return r.o.GetCreatedAt() }
+func (r *offerResolver) UpdatedAt() string { return r.o.GetUpdatedAt() }
+
+func (r *offerResolver) Master(ctx context.Context) (*userResolver, error) {
+	return loadUser(ctx, r.o.GetMaster().GetId())
+}
+
+func (r *offerResolver) Order() *orderResolver {
+	if r.o.GetOrder() == nil {
+		return nil
+	}
+	return &orderResolver{r.o.GetOrder()}
+}
+
+type offerEventResolver struct{ ev offer.Event }
+
+func (r *offerEventResolver) Action() string        { return r.ev.Action }
+func (r *offerEventResolver) Offer() *offerResolver { return &offerResolver{r.ev.Offer} }
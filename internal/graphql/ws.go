@@ -0,0 +1,263 @@
+// internal/graphql/ws.go
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	qerrors "github.com/graph-gophers/graphql-go/errors"
+
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/auth"
+)
+
+// wsProtocol — подпротокол graphql-transport-ws (graphql-ws).
+const wsProtocol = "graphql-transport-ws"
+
+const (
+	initTimeout = 10 * time.Second
+	writeWait   = 10 * time.Second
+	pongWait    = 60 * time.Second
+	pingPeriod  = (pongWait * 9) / 10
+)
+
+// Коды закрытия из спецификации graphql-transport-ws.
+const (
+	closeBadMessage      = 4400
+	closeUnauthorized    = 4401
+	closeForbidden       = 4403
+	closeBadProtocol     = 4406
+	closeInitTimeout     = 4408
+	closeDuplicateID     = 4409
+	closeTooManyInitReqs = 4429
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	Subprotocols:    []string{wsProtocol},
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// wsMessage — сообщение протокола в обе стороны.
+type wsMessage struct {
+	Type    string          `json:"type"`
+	ID      string          `json:"id,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// session — одно WebSocket-соединение с его подписками.
+type session struct {
+	conn    *websocket.Conn
+	schema  *graphqlgo.Schema
+	clients Clients
+	gin     *gin.Context
+
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	subs    map[string]context.CancelFunc
+}
+
+// SubscriptionHandler обслуживает подписки (и запросы) по WebSocket.
+// Подключиться может только пользователь с действующим токеном в cookie.
+func SubscriptionHandler(schema *graphqlgo.Schema, clients Clients) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		s := &session{
+			conn:    conn,
+			schema:  schema,
+			clients: clients,
+			gin:     c,
+			subs:    make(map[string]context.CancelFunc),
+		}
+		defer s.cancelAll()
+
+		if conn.Subprotocol() != wsProtocol {
+			s.close(closeBadProtocol, "Subprotocol not acceptable")
+			return
+		}
+		s.run()
+	}
+}
+
+func (s *session) run() {
+	s.conn.SetReadDeadline(time.Now().Add(initTimeout))
+	acked := false
+
+	for {
+		_, raw, err := s.conn.ReadMessage()
+		if err != nil {
+			if !acked {
+				s.close(closeInitTimeout, "Connection initialisation timeout")
+			}
+			return
+		}
+		var m wsMessage
+		if json.Unmarshal(raw, &m) != nil || m.Type == "" {
+			s.close(closeBadMessage, "Invalid message")
+			return
+		}
+
+		switch m.Type {
+		case "connection_init":
+			if acked {
+				s.close(closeTooManyInitReqs, "Too many initialisation requests")
+				return
+			}
+			if _, ok := auth.CurrentUser(s.gin); !ok {
+				s.close(closeForbidden, "Forbidden")
+				return
+			}
+			acked = true
+			s.keepAlive()
+			s.write(wsMessage{Type: "connection_ack"})
+
+		case "ping":
+			s.write(wsMessage{Type: "pong"})
+
+		case "pong":
+
+		case "subscribe":
+			if !acked {
+				s.close(closeUnauthorized, "Unauthorized")
+				return
+			}
+			if m.ID == "" {
+				s.close(closeBadMessage, "Invalid message")
+				return
+			}
+			var req request
+			if json.Unmarshal(m.Payload, &req) != nil || req.Query == "" {
+				s.close(closeBadMessage, "Invalid message")
+				return
+			}
+			if !s.subscribe(m.ID, req) {
+				s.close(closeDuplicateID, "Subscriber for "+m.ID+" already exists")
+				return
+			}
+
+		case "complete":
+			s.mu.Lock()
+			if cancel, ok := s.subs[m.ID]; ok {
+				cancel()
+				delete(s.subs, m.ID)
+			}
+			s.mu.Unlock()
+
+		default:
+			s.close(closeBadMessage, "Invalid message")
+			return
+		}
+	}
+}
+
+// subscribe запускает операцию id. Возвращает false, если id уже занят.
+func (s *session) subscribe(id string, req request) bool {
+	s.mu.Lock()
+	if _, exists := s.subs[id]; exists {
+		s.mu.Unlock()
+		return false
+	}
+	ctx, cancel := context.WithCancel(requestContext(s.gin, s.clients))
+	s.subs[id] = cancel
+	s.mu.Unlock()
+
+	if err := checkComplexity(req.Query); err != nil {
+		s.finish(id, cancel)
+		s.writeErrors(id, err.Error())
+		return true
+	}
+	responses, err := s.schema.Subscribe(ctx, req.Query, req.OperationName, req.Variables)
+	if err != nil {
+		s.finish(id, cancel)
+		s.writeErrors(id, err.Error())
+		return true
+	}
+
+	go func() {
+		for resp := range responses {
+			s.write(wsMessage{Type: "next", ID: id, Payload: mustMarshal(resp)})
+		}
+		// если клиент сам прислал complete, отвечать не нужно
+		if ctx.Err() == nil {
+			s.finish(id, cancel)
+			s.write(wsMessage{Type: "complete", ID: id})
+		}
+	}()
+	return true
+}
+
+// finish снимает подписку id.
+func (s *session) finish(id string, cancel context.CancelFunc) {
+	cancel()
+	s.mu.Lock()
+	delete(s.subs, id)
+	s.mu.Unlock()
+}
+
+func (s *session) cancelAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, cancel := range s.subs {
+		cancel()
+		delete(s.subs, id)
+	}
+}
+
+// keepAlive продлевает чтение по pong и шлёт ping, пока соединение живо.
+func (s *session) keepAlive() {
+	s.conn.SetReadDeadline(time.Now().Add(pongWait))
+	s.conn.SetPongHandler(func(string) error {
+		s.conn.SetReadDeadline(time.Now().Add(pongWait))
+		return nil
+	})
+	go func() {
+		ticker := time.NewTicker(pingPeriod)
+		defer ticker.Stop()
+		for range ticker.C {
+			s.writeMu.Lock()
+			s.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			err := s.conn.WriteMessage(websocket.PingMessage, nil)
+			s.writeMu.Unlock()
+			if err != nil {
+				return
+			}
+		}
+	}()
+}
+
+func (s *session) write(m wsMessage) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	s.conn.WriteJSON(m)
+}
+
+func (s *session) writeErrors(id, msg string) {
+	s.write(wsMessage{Type: "error", ID: id, Payload: mustMarshal([]*qerrors.QueryError{{Message: msg}})})
+}
+
+func (s *session) close(code int, reason string) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(code, reason), time.Now().Add(writeWait))
+}
+
+func mustMarshal(v interface{}) json.RawMessage {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return json.RawMessage(`null`)
+	}
+	return b
+}
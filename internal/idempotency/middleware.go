@@ -0,0 +1,135 @@
+// internal/idempotency/middleware.go
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderKey — заголовок с ключом идемпотентности.
+const HeaderKey = "Idempotency-Key"
+
+const maxKeyLength = 255
+
+// ScopedKey связывает ключ клиента с пользователем, чтобы ключи разных
+// пользователей не пересекались.
+func ScopedKey(userID, scope, key string) string {
+	return userID + "|" + scope + "|" + key
+}
+
+// Fingerprint считает отпечаток тела запроса.
+func Fingerprint(parts ...[]byte) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write(p)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// bodyRecorder дублирует тело ответа в буфер.
+type bodyRecorder struct {
+	gin.ResponseWriter
+	buf bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(b []byte) (int, error) {
+	w.buf.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	w.buf.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Middleware сохраняет первый ответ на запрос с заголовком Idempotency-Key
+// и повторяет его при ретраях. Запросы без заголовка проходят как есть.
+// Анонимный запрос с заголовком получает 400: ключи хранятся по
+// пользователю, а общий для всех анонимов ключ позволил бы одному получить
+// чужой ответ.
+func Middleware(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(HeaderKey)
+		if key == "" {
+			c.Next()
+			return
+		}
+		claims, ok := auth.CurrentUser(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Idempotency-Key доступен только авторизованным пользователям",
+			})
+			return
+		}
+		if len(key) > maxKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "слишком длинный Idempotency-Key",
+			})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "некорректный запрос",
+			})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		scoped := ScopedKey(claims.UserID, c.Request.Method+" "+c.FullPath(), key)
+		rec, state := store.Begin(scoped, Fingerprint([]byte(c.Request.URL.RawQuery), body))
+		switch state {
+		case Replay:
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(rec.Status, rec.ContentType, rec.Body)
+			c.Abort()
+			return
+		case InFlight:
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+				"success": false,
+				"message": "запрос с этим Idempotency-Key ещё выполняется",
+			})
+			return
+		case Mismatch:
+			c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+				"success": false,
+				"message": "Idempotency-Key уже использован с другим телом запроса",
+			})
+			return
+		}
+
+		completed := false
+		defer func() {
+			// паника в хендлере: ключ освобождаем, иначе он зависнет в InFlight
+			if !completed {
+				store.Abort(scoped)
+			}
+		}()
+
+		w := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+
+		// ошибки сервера не сохраняем, чтобы клиент мог повторить запрос
+		if w.Status() >= http.StatusInternalServerError {
+			return
+		}
+		completed = true
+		store.Complete(scoped, Record{
+			Status:      w.Status(),
+			ContentType: w.Header().Get("Content-Type"),
+			Body:        w.buf.Bytes(),
+		})
+	}
+}
@@ -0,0 +1,151 @@
+// internal/idempotency/middleware_test.go
+package idempotency
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Ostap00034/course-work-backend-auth-service/util/jwt"
+)
+
+// testServer — POST /items за Middleware. Каждый выполненный запрос
+// получает новый номер; тело "fail" отвечает 500, "slow" ждёт release.
+type testServer struct {
+	r       *gin.Engine
+	mu      sync.Mutex
+	calls   int
+	started chan struct{}
+	release chan struct{}
+}
+
+func newTestServer(store *Store) *testServer {
+	gin.SetMode(gin.TestMode)
+	s := &testServer{r: gin.New(), started: make(chan struct{}, 1), release: make(chan struct{})}
+	s.r.POST("/items", Middleware(store), func(c *gin.Context) {
+		body, _ := c.GetRawData()
+		s.mu.Lock()
+		s.calls++
+		n := s.calls
+		s.mu.Unlock()
+		switch string(body) {
+		case "fail":
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false})
+			return
+		case "slow":
+			s.started <- struct{}{}
+			<-s.release
+		}
+		c.JSON(http.StatusCreated, gin.H{"n": n})
+	})
+	return s
+}
+
+// post отправляет запрос от имени userID; пустой userID — аноним.
+func (s *testServer) post(t *testing.T, userID, key, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(HeaderKey, key)
+	}
+	if userID != "" {
+		token, err := jwt.GenerateToken(jwt.NewClaims(userID, "client", time.Now().Add(time.Hour)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+	}
+	w := httptest.NewRecorder()
+	s.r.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddleware(t *testing.T) {
+	s := newTestServer(NewStore(time.Minute))
+	steps := []struct {
+		name, userID, key, body string
+		code                    int
+		response                string
+		replayed                bool
+	}{
+		{"first request", "u1", "k1", "a", http.StatusCreated, `{"n":1}`, false},
+		{"retry is replayed", "u1", "k1", "a", http.StatusCreated, `{"n":1}`, true},
+		{"same key, other body", "u1", "k1", "b", http.StatusUnprocessableEntity, "", false},
+		{"same key, other user", "u2", "k1", "a", http.StatusCreated, `{"n":2}`, false},
+		{"without a key", "u1", "", "a", http.StatusCreated, `{"n":3}`, false},
+		{"anonymous with a key", "", "k1", "a", http.StatusBadRequest, "", false},
+		{"anonymous without a key", "", "", "a", http.StatusCreated, `{"n":4}`, false},
+		{"key is too long", "u1", strings.Repeat("k", maxKeyLength+1), "a", http.StatusBadRequest, "", false},
+		{"server error is not stored", "u1", "k2", "fail", http.StatusInternalServerError, "", false},
+		{"retry after a server error runs again", "u1", "k2", "fail", http.StatusInternalServerError, "", false},
+	}
+	for _, step := range steps {
+		w := s.post(t, step.userID, step.key, step.body)
+		if w.Code != step.code {
+			t.Fatalf("%s: got %d %s, want %d", step.name, w.Code, w.Body, step.code)
+		}
+		if step.response != "" && w.Body.String() != step.response {
+			t.Fatalf("%s: got %s, want %s", step.name, w.Body, step.response)
+		}
+		if got := w.Header().Get("Idempotent-Replayed") == "true"; got != step.replayed {
+			t.Fatalf("%s: replayed = %v", step.name, got)
+		}
+	}
+	if s.calls != 6 {
+		t.Fatalf("handler ran %d times, want 6", s.calls)
+	}
+}
+
+func TestMiddlewareInFlight(t *testing.T) {
+	s := newTestServer(NewStore(time.Minute))
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- s.post(t, "u1", "k1", "slow") }()
+	<-s.started
+
+	if w := s.post(t, "u1", "k1", "slow"); w.Code != http.StatusConflict {
+		t.Fatalf("concurrent retry: got %d %s, want 409", w.Code, w.Body)
+	}
+	close(s.release)
+	first := <-done
+	if first.Code != http.StatusCreated {
+		t.Fatalf("first request: got %d %s", first.Code, first.Body)
+	}
+	if w := s.post(t, "u1", "k1", "slow"); w.Body.String() != first.Body.String() {
+		t.Fatalf("retry after completion: got %s, want %s", w.Body, first.Body)
+	}
+}
+
+func TestStoreTTL(t *testing.T) {
+	store := NewStore(time.Hour)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	if _, state := store.Begin("k", "a"); state != Started {
+		t.Fatalf("first Begin: %v", state)
+	}
+	store.Complete("k", Record{Status: http.StatusCreated, Body: []byte("1")})
+	now = now.Add(59 * time.Minute)
+	if rec, state := store.Begin("k", "a"); state != Replay || string(rec.Body) != "1" {
+		t.Fatalf("within ttl: %v %q", state, rec.Body)
+	}
+	// после окна ключ можно использовать заново, в том числе с другим телом
+	now = now.Add(2 * time.Minute)
+	if _, state := store.Begin("k", "b"); state != Started {
+		t.Fatalf("after ttl: %v", state)
+	}
+	// просроченные записи вычищаются
+	for i := 0; i < 10; i++ {
+		store.Begin("old"+strconv.Itoa(i), "a")
+	}
+	now = now.Add(2 * time.Hour)
+	store.Begin("fresh", "a")
+	if len(store.items) != 1 {
+		t.Fatalf("%d items after sweep, want 1", len(store.items))
+	}
+}
@@ -0,0 +1,107 @@
+// internal/idempotency/store.go
+package idempotency
+
+import (
+	"sync"
+	"time"
+)
+
+// State — результат попытки начать запрос с ключом идемпотентности.
+type State int
+
+const (
+	// Started — ключ встречается впервые, запрос нужно выполнить.
+	Started State = iota
+	// Replay — ответ уже сохранён, его нужно вернуть повторно.
+	Replay
+	// InFlight — запрос с этим ключом ещё выполняется.
+	InFlight
+	// Mismatch — ключ уже использован с другим телом запроса.
+	Mismatch
+)
+
+// Record — сохранённый первый ответ на запрос.
+type Record struct {
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+type entry struct {
+	fingerprint string
+	done        bool
+	record      Record
+	expiresAt   time.Time
+}
+
+// Store хранит первые ответы по ключу пользователь+ключ в течение окна ttl.
+type Store struct {
+	ttl       time.Duration
+	mu        sync.Mutex
+	items     map[string]*entry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewStore создаёт in-memory хранилище с окном хранения ttl.
+func NewStore(ttl time.Duration) *Store {
+	return &Store{
+		ttl:   ttl,
+		items: make(map[string]*entry),
+		now:   time.Now,
+	}
+}
+
+// Begin регистрирует запрос с ключом key и отпечатком тела fingerprint.
+// При состоянии Replay возвращается сохранённый ответ.
+func (s *Store) Begin(key, fingerprint string) (Record, State) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	if e, ok := s.items[key]; ok && now.Before(e.expiresAt) {
+		switch {
+		case e.fingerprint != fingerprint:
+			return Record{}, Mismatch
+		case !e.done:
+			return Record{}, InFlight
+		default:
+			return e.record, Replay
+		}
+	}
+
+	s.items[key] = &entry{fingerprint: fingerprint, expiresAt: now.Add(s.ttl)}
+	return Record{}, Started
+}
+
+// Complete сохраняет ответ на запрос, начатый через Begin.
+func (s *Store) Complete(key string, rec Record) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.items[key]; ok {
+		e.done = true
+		e.record = rec
+	}
+}
+
+// Abort забывает ключ, чтобы запрос можно было повторить (например, после 5xx).
+func (s *Store) Abort(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.items, key)
+}
+
+// sweep удаляет просроченные записи не чаще раза в минуту.
+func (s *Store) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for k, e := range s.items {
+		if now.After(e.expiresAt) {
+			delete(s.items, k)
+		}
+	}
+}
@@ -0,0 +1,328 @@
+// internal/media/handler.go
+package media
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/auth"
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/order"
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/storage"
+	categorypbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/category/v1"
+	orderpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/order/v1"
+)
+
+// maxPhotosPerRequest — сколько фотографий заказа можно загрузить за раз.
+const maxPhotosPerRequest = 10
+
+// readFiles читает файлы из multipart-поля field, ограничивая общий размер тела.
+func readFiles(c *gin.Context, field string, rules imageRules, maxFiles int) ([][]byte, string) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, rules.maxBytes*int64(maxFiles)+1<<20)
+	if err := c.Request.ParseMultipartForm(rules.maxBytes); err != nil {
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			return nil, "слишком большой запрос"
+		}
+		return nil, "ожидается multipart/form-data"
+	}
+
+	headers := c.Request.MultipartForm.File[field]
+	if len(headers) == 0 {
+		return nil, fmt.Sprintf("файл в поле %s обязателен", field)
+	}
+	if len(headers) > maxFiles {
+		return nil, fmt.Sprintf("за один запрос можно загрузить не больше %d файлов", maxFiles)
+	}
+
+	files := make([][]byte, 0, len(headers))
+	for _, h := range headers {
+		data, err := readFile(h, rules.maxBytes)
+		if err != nil {
+			return nil, "не удалось прочитать файл"
+		}
+		files = append(files, data)
+	}
+	return files, ""
+}
+
+// readFile читает не больше limit+1 байт, чтобы processImage увидел превышение.
+func readFile(h *multipart.FileHeader, limit int64) ([]byte, error) {
+	f, err := h.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(io.LimitReader(f, limit+1))
+}
+
+// imageLinks собирает подписанные ссылки на картинку и миниатюру.
+func imageLinks(signer *storage.Signer, key, thumbKey string, img *processedImage) *Image {
+	out := &Image{
+		URL:          signer.URL(key),
+		ThumbnailURL: signer.URL(thumbKey),
+	}
+	if img != nil {
+		out.ContentType = img.ContentType
+		out.Width = img.Width
+		out.Height = img.Height
+	}
+	return out
+}
+
+func iconKeys(categoryID string) (string, string) {
+	return "categories/" + categoryID + "/icon", "categories/" + categoryID + "/icon_thumb"
+}
+
+func photoPrefix(orderID string) string {
+	return "orders/" + orderID + "/photos"
+}
+
+func thumbKeyFor(photoKey string) string {
+	dir, name := path.Split(photoKey)
+	return strings.TrimSuffix(dir, "photos/") + "thumbs/" + name
+}
+
+// UploadCategoryIconHandler заменяет иконку категории.
+func UploadCategoryIconHandler(store storage.Storage, signer *storage.Signer, categoryClient categorypbv1.CategoryServiceClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		if _, err := uuid.Parse(id); err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse("неправильный формат id категории", nil))
+			return
+		}
+		if _, err := categoryClient.GetCategoryById(c, &categorypbv1.GetCategoryByIdRequest{Id: id}); err != nil {
+			if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
+				c.JSON(http.StatusNotFound, errorResponse(st.Message(), nil))
+			} else {
+				c.JSON(http.StatusInternalServerError, errorResponse("внутренняя ошибка сервера", nil))
+			}
+			return
+		}
+
+		files, msg := readFiles(c, "icon", iconRules, 1)
+		if msg != "" {
+			c.JSON(http.StatusBadRequest, errorResponse("ошибка валидации", map[string]string{"icon": msg}))
+			return
+		}
+		img, msg := processImage(c.Request.Context(), files[0], iconRules)
+		if msg != "" {
+			c.JSON(http.StatusBadRequest, errorResponse("ошибка валидации", map[string]string{"icon": msg}))
+			return
+		}
+
+		key, thumbKey := iconKeys(id)
+		if err := store.Put(c, key, files[0]); err != nil {
+			c.JSON(http.StatusInternalServerError, errorResponse("не удалось сохранить файл", nil))
+			return
+		}
+		if err := store.Put(c, thumbKey, img.Thumb); err != nil {
+			c.JSON(http.StatusInternalServerError, errorResponse("не удалось сохранить файл", nil))
+			return
+		}
+
+		c.JSON(http.StatusOK, ImageResponse{
+			Response: Response{Success: true, Message: "успешно"},
+			Image:    imageLinks(signer, key, thumbKey, img),
+		})
+	}
+}
+
+// GetCategoryIconHandler возвращает ссылки на иконку категории.
+func GetCategoryIconHandler(store storage.Storage, signer *storage.Signer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		if _, err := uuid.Parse(id); err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse("неправильный формат id категории", nil))
+			return
+		}
+		key, thumbKey := iconKeys(id)
+		obj, err := store.Open(c, key)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				c.JSON(http.StatusNotFound, errorResponse("у категории нет иконки", nil))
+			} else {
+				c.JSON(http.StatusInternalServerError, errorResponse("внутренняя ошибка сервера", nil))
+			}
+			return
+		}
+		obj.Close()
+		c.JSON(http.StatusOK, ImageResponse{
+			Response: Response{Success: true, Message: "успешно"},
+			Image:    imageLinks(signer, key, thumbKey, nil),
+		})
+	}
+}
+
+// UploadOrderPhotosHandler добавляет фотографии к заказу. Загружать может
+// клиент заказа или администратор.
+func UploadOrderPhotosHandler(store storage.Storage, signer *storage.Signer, orderClient orderpbv1.OrderServiceClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		if _, err := uuid.Parse(id); err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse("неверный формат id", nil))
+			return
+		}
+		claims, ok := auth.CurrentUser(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, errorResponse("требуется авторизация", nil))
+			return
+		}
+		resp, err := orderClient.GetOrderById(c, &orderpbv1.GetOrderByIdRequest{Id: id})
+		if err != nil {
+			if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
+				c.JSON(http.StatusNotFound, errorResponse(st.Message(), nil))
+			} else {
+				c.JSON(http.StatusInternalServerError, errorResponse("внутренняя ошибка сервера", nil))
+			}
+			return
+		}
+		if claims.Role != "admin" && resp.Order.GetClient().GetId() != claims.UserID {
+			c.JSON(http.StatusForbidden, errorResponse("загружать фотографии может только автор заказа", nil))
+			return
+		}
+
+		files, msg := readFiles(c, "photos", photoRules, maxPhotosPerRequest)
+		if msg != "" {
+			c.JSON(http.StatusBadRequest, errorResponse("ошибка валидации", map[string]string{"photos": msg}))
+			return
+		}
+
+		// картинки декодируются по одной: каждая может занимать сотню мегабайт
+		processed := make([]*processedImage, len(files))
+		errs := make(map[string]string)
+		for i, data := range files {
+			img, msg := processImage(c.Request.Context(), data, photoRules)
+			if msg != "" {
+				errs[fmt.Sprintf("photos[%d]", i)] = msg
+				continue
+			}
+			processed[i] = img
+		}
+		if len(errs) > 0 {
+			c.JSON(http.StatusBadRequest, errorResponse("ошибка валидации", errs))
+			return
+		}
+
+		images := make([]*Image, 0, len(files))
+		for i, data := range files {
+			// ключ по содержимому: повторная загрузка того же файла не плодит копии
+			sum := sha256.Sum256(data)
+			key := photoPrefix(id) + "/" + hex.EncodeToString(sum[:16])
+			thumbKey := thumbKeyFor(key)
+			if err := store.Put(c, key, data); err != nil {
+				c.JSON(http.StatusInternalServerError, errorResponse("не удалось сохранить файл", nil))
+				return
+			}
+			if err := store.Put(c, thumbKey, processed[i].Thumb); err != nil {
+				c.JSON(http.StatusInternalServerError, errorResponse("не удалось сохранить файл", nil))
+				return
+			}
+			images = append(images, imageLinks(signer, key, thumbKey, processed[i]))
+		}
+
+		c.JSON(http.StatusOK, ImagesResponse{
+			Response: Response{Success: true, Message: "успешно"},
+			Images:   images,
+		})
+	}
+}
+
+// GetOrderPhotosHandler возвращает ссылки на фотографии заказа тем, кто может
+// следить за заказом (order.CanFollow).
+func GetOrderPhotosHandler(store storage.Storage, signer *storage.Signer, orderClient orderpbv1.OrderServiceClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		if _, err := uuid.Parse(id); err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse("неверный формат id", nil))
+			return
+		}
+		claims, ok := auth.CurrentUser(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, errorResponse("требуется авторизация", nil))
+			return
+		}
+		resp, err := orderClient.GetOrderById(c.Request.Context(), &orderpbv1.GetOrderByIdRequest{Id: id})
+		if err != nil {
+			if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
+				c.JSON(http.StatusNotFound, errorResponse(st.Message(), nil))
+			} else {
+				c.JSON(http.StatusInternalServerError, errorResponse("внутренняя ошибка сервера", nil))
+			}
+			return
+		}
+		if !order.CanFollow(resp.Order, claims.UserID, claims.Role) {
+			c.JSON(http.StatusForbidden, errorResponse("нет доступа к фотографиям заказа", nil))
+			return
+		}
+
+		keys, err := store.List(c, photoPrefix(id))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, errorResponse("внутренняя ошибка сервера", nil))
+			return
+		}
+		images := make([]*Image, 0, len(keys))
+		for _, key := range keys {
+			images = append(images, imageLinks(signer, key, thumbKeyFor(key), nil))
+		}
+		c.JSON(http.StatusOK, ImagesResponse{
+			Response: Response{Success: true, Message: "успешно"},
+			Images:   images,
+		})
+	}
+}
+
+// ServeHandler отдаёт объект по подписанной ссылке.
+func ServeHandler(store storage.Storage, signer *storage.Signer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimPrefix(c.Param("key"), "/")
+		expiresAt, ok := signer.Verify(key, c.Query("expires"), c.Query("sig"))
+		if !ok {
+			c.JSON(http.StatusForbidden, errorResponse("ссылка недействительна или истекла", nil))
+			return
+		}
+		obj, err := store.Open(c, key)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				c.JSON(http.StatusNotFound, errorResponse("файл не найден", nil))
+			} else {
+				c.JSON(http.StatusInternalServerError, errorResponse("внутренняя ошибка сервера", nil))
+			}
+			return
+		}
+		defer obj.Close()
+
+		// ссылка подписана, поэтому ответ можно кэшировать до истечения подписи
+		maxAge := int(time.Until(expiresAt).Seconds())
+		c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", maxAge))
+		c.Header("X-Content-Type-Options", "nosniff")
+		http.ServeContent(c.Writer, c.Request, path.Base(key), obj.ModTime, obj)
+	}
+}
+
+// RegisterHandlers вешает маршруты загрузки и раздачи картинок на корневую группу api.
+func RegisterHandlers(
+	r gin.IRouter,
+	store storage.Storage,
+	signer *storage.Signer,
+	categoryClient categorypbv1.CategoryServiceClient,
+	orderClient orderpbv1.OrderServiceClient,
+) {
+	r.POST("/categories/:id/icon", auth.AdminOnly(), UploadCategoryIconHandler(store, signer, categoryClient))
+	r.GET("/categories/:id/icon", GetCategoryIconHandler(store, signer))
+	r.POST("/orders/:id/photos", UploadOrderPhotosHandler(store, signer, orderClient))
+	r.GET("/orders/:id/photos", GetOrderPhotosHandler(store, signer, orderClient))
+	r.GET("/media/*key", ServeHandler(store, signer))
+}
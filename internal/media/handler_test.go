@@ -0,0 +1,165 @@
+// internal/media/handler_test.go
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/storage"
+	commonpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/common/v1"
+	orderpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/order/v1"
+	"github.com/Ostap00034/course-work-backend-auth-service/util/jwt"
+)
+
+const (
+	testOrderID  = "00000000-0000-0000-0000-00000000e001"
+	testClientID = "00000000-0000-0000-0000-00000000c001"
+	testMasterID = "00000000-0000-0000-0000-00000000a001"
+	testOtherID  = "00000000-0000-0000-0000-00000000b001"
+)
+
+// fakeOrders знает один заказ клиента testClientID в статусе status.
+type fakeOrders struct {
+	orderpbv1.OrderServiceClient
+	status string
+}
+
+func (f fakeOrders) GetOrderById(_ context.Context, req *orderpbv1.GetOrderByIdRequest, _ ...grpc.CallOption) (*orderpbv1.GetOrderByIdResponse, error) {
+	if req.Id != testOrderID {
+		return nil, status.Error(codes.NotFound, "заказ не найден")
+	}
+	return &orderpbv1.GetOrderByIdResponse{Order: &commonpbv1.OrderData{
+		Id:     testOrderID,
+		Status: f.status,
+		Client: &commonpbv1.UserData{Id: testClientID},
+	}}, nil
+}
+
+func newTestRouter(t *testing.T, orderStatus string) *gin.Engine {
+	t.Helper()
+	store, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterHandlers(r, store, storage.NewSigner([]byte("secret"), "/media", time.Hour), nil, fakeOrders{status: orderStatus})
+	return r
+}
+
+// serve выполняет запрос от имени пользователя с ролью role; пустой userID —
+// запрос без cookie.
+func serve(t *testing.T, r *gin.Engine, req *http.Request, userID, role string) *httptest.ResponseRecorder {
+	t.Helper()
+	if userID != "" {
+		token, err := jwt.GenerateToken(jwt.NewClaims(userID, role, time.Now().Add(time.Hour)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func uploadRequest(t *testing.T, field string, files ...[]byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for i, data := range files {
+		fw, err := mw.CreateFormFile(field, "photo"+string(rune('a'+i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write(data)
+	}
+	mw.Close()
+	req := httptest.NewRequest("POST", "/orders/"+testOrderID+"/photos", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestOrderPhotos(t *testing.T) {
+	r := newTestRouter(t, "open")
+	photo := encodeJPEG(t, solid(400, 300, red, blue))
+
+	if w := serve(t, r, uploadRequest(t, "photos", photo), testOtherID, "client"); w.Code != http.StatusForbidden {
+		t.Fatalf("foreign upload: got %d %s, want 403", w.Code, w.Body)
+	}
+	w := serve(t, r, uploadRequest(t, "photos", photo, []byte("not an image")), testClientID, "client")
+	if w.Code != http.StatusBadRequest || !bytes.Contains(w.Body.Bytes(), []byte("photos[1]")) {
+		t.Fatalf("invalid second file: got %d %s, want 400 for photos[1]", w.Code, w.Body)
+	}
+	w = serve(t, r, uploadRequest(t, "photos", photo), testClientID, "client")
+	if w.Code != http.StatusOK {
+		t.Fatalf("upload: got %d %s, want 200", w.Code, w.Body)
+	}
+	var uploaded ImagesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &uploaded); err != nil {
+		t.Fatal(err)
+	}
+	if len(uploaded.Images) != 1 || uploaded.Images[0].Width != 400 || uploaded.Images[0].ContentType != "image/jpeg" {
+		t.Fatalf("uploaded %+v", uploaded.Images)
+	}
+
+	list := func() *http.Request { return httptest.NewRequest("GET", "/orders/"+testOrderID+"/photos", nil) }
+	for _, tt := range []struct {
+		name, userID, role string
+		code               int
+	}{
+		{"anonymous", "", "", http.StatusUnauthorized},
+		{"foreign client", testOtherID, "client", http.StatusForbidden},
+		{"owner", testClientID, "client", http.StatusOK},
+		{"master of an open order", testMasterID, "master", http.StatusOK},
+		{"admin", testOtherID, "admin", http.StatusOK},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := serve(t, r, list(), tt.userID, tt.role); w.Code != tt.code {
+				t.Fatalf("got %d %s, want %d", w.Code, w.Body, tt.code)
+			}
+		})
+	}
+
+	var listed ImagesResponse
+	if err := json.Unmarshal(serve(t, r, list(), testClientID, "client").Body.Bytes(), &listed); err != nil {
+		t.Fatal(err)
+	}
+	if len(listed.Images) != 1 {
+		t.Fatalf("listed %d photos, want 1", len(listed.Images))
+	}
+	// ссылки работают без cookie, миниатюра — уменьшенная копия
+	for link, want := range map[string]int{listed.Images[0].URL: 400, listed.Images[0].ThumbnailURL: 320} {
+		w := serve(t, r, httptest.NewRequest("GET", link, nil), "", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: got %d, want 200", link, w.Code)
+		}
+		cfg, _, err := image.DecodeConfig(w.Body)
+		if err != nil || cfg.Width != want {
+			t.Fatalf("GET %s: width %d (%v), want %d", link, cfg.Width, err, want)
+		}
+	}
+	if w := serve(t, r, httptest.NewRequest("GET", listed.Images[0].URL+"0", nil), "", ""); w.Code != http.StatusForbidden {
+		t.Fatalf("tampered link: got %d, want 403", w.Code)
+	}
+}
+
+func TestOrderPhotosOfAssignedOrder(t *testing.T) {
+	r := newTestRouter(t, "assigned")
+	req := httptest.NewRequest("GET", "/orders/"+testOrderID+"/photos", nil)
+	if w := serve(t, r, req, testMasterID, "master"); w.Code != http.StatusForbidden {
+		t.Fatalf("master of someone else's order: got %d %s, want 403", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,176 @@
+// internal/media/image.go
+package media
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+// imageRules — ограничения для загружаемой картинки.
+type imageRules struct {
+	maxBytes  int64
+	minWidth  int
+	minHeight int
+	maxWidth  int
+	maxHeight int
+	thumbSize int // максимальная сторона миниатюры
+}
+
+var (
+	iconRules = imageRules{
+		maxBytes: 1 << 20, minWidth: 32, minHeight: 32, maxWidth: 1024, maxHeight: 1024, thumbSize: 64,
+	}
+	photoRules = imageRules{
+		maxBytes: 10 << 20, minWidth: 100, minHeight: 100, maxWidth: 8000, maxHeight: 8000, thumbSize: 320,
+	}
+)
+
+// maxPixels — бюджет на одну картинку: распакованная в память она занимает
+// до maxPixels*4 байт, поэтому габариты проверяются до декодирования.
+const maxPixels = 40_000_000
+
+// decodeSlots ограничивает число картинок, которые шлюз декодирует
+// одновременно во всех запросах, — иначе несколько загрузок по десятку
+// больших фотографий исчерпают память.
+var decodeSlots = make(chan struct{}, 2)
+
+// allowedTypes — MIME-типы, определённые по содержимому файла.
+var allowedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// processedImage — проверенная картинка и её миниатюра.
+type processedImage struct {
+	ContentType string
+	Width       int
+	Height      int
+	Thumb       []byte
+}
+
+// processImage проверяет тип по содержимому, размер и габариты картинки
+// и строит миниатюру. Возвращает текст ошибки для клиента.
+func processImage(ctx context.Context, data []byte, rules imageRules) (*processedImage, string) {
+	if int64(len(data)) > rules.maxBytes {
+		return nil, fmt.Sprintf("размер файла не должен превышать %d КБ", rules.maxBytes>>10)
+	}
+	contentType := http.DetectContentType(data)
+	if !allowedTypes[contentType] {
+		return nil, "поддерживаются только изображения JPEG, PNG и GIF"
+	}
+
+	// габариты проверяем до полного декодирования, чтобы не распаковывать огромные картинки
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "не удалось прочитать изображение"
+	}
+	if cfg.Width < rules.minWidth || cfg.Height < rules.minHeight {
+		return nil, fmt.Sprintf("минимальный размер изображения %dx%d", rules.minWidth, rules.minHeight)
+	}
+	if cfg.Width > rules.maxWidth || cfg.Height > rules.maxHeight {
+		return nil, fmt.Sprintf("максимальный размер изображения %dx%d", rules.maxWidth, rules.maxHeight)
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, fmt.Sprintf("изображение не должно превышать %d Мп", maxPixels/1_000_000)
+	}
+
+	select {
+	case decodeSlots <- struct{}{}:
+		defer func() { <-decodeSlots }()
+	case <-ctx.Done():
+		return nil, "запрос отменён"
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "не удалось прочитать изображение"
+	}
+
+	var buf bytes.Buffer
+	thumb := resize(img, rules.thumbSize)
+	if contentType == "image/jpeg" {
+		err = jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 85})
+	} else {
+		err = png.Encode(&buf, thumb)
+	}
+	if err != nil {
+		return nil, "не удалось создать миниатюру"
+	}
+
+	return &processedImage{
+		ContentType: contentType,
+		Width:       cfg.Width,
+		Height:      cfg.Height,
+		Thumb:       buf.Bytes(),
+	}, ""
+}
+
+// resize уменьшает картинку так, чтобы большая сторона была не больше maxSide,
+// усредняя пиксели исходника (box-фильтр). Маленькие картинки не увеличиваются.
+func resize(src image.Image, maxSide int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxSide && h <= maxSide {
+		return src
+	}
+	tw, th := maxSide, h*maxSide/w
+	if h > w {
+		tw, th = w*maxSide/h, maxSide
+	}
+	if tw < 1 {
+		tw = 1
+	}
+	if th < 1 {
+		th = 1
+	}
+
+	// пиксели читаются напрямую из Pix: вызов At на каждый пиксель в разы
+	// медленнее. Остальные форматы переводятся в RGBA через draw, у которого
+	// есть быстрые пути для YCbCr (JPEG) и палитры (GIF, PNG), — по полосе
+	// строк на строку миниатюры, чтобы не держать вторую копию всей картинки.
+	rgba, ok := src.(*image.RGBA)
+	var band *image.RGBA
+	if !ok {
+		band = image.NewRGBA(image.Rect(0, 0, w, (h+th-1)/th))
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		y0, y1 := b.Min.Y+y*h/th, b.Min.Y+(y+1)*h/th
+		if band != nil {
+			r := image.Rect(b.Min.X, y0, b.Max.X, y1)
+			rgba = &image.RGBA{Pix: band.Pix, Stride: band.Stride, Rect: r}
+			draw.Draw(rgba, r, src, r.Min, draw.Src)
+		}
+		for x := 0; x < tw; x++ {
+			x0, x1 := b.Min.X+x*w/tw, b.Min.X+(x+1)*w/tw
+			var sum [4]uint64
+			var n uint64
+			for sy := y0; sy < y1; sy++ {
+				row := rgba.Pix[rgba.PixOffset(x0, sy):rgba.PixOffset(x1, sy)]
+				for i := 0; i < len(row); i += 4 {
+					sum[0] += uint64(row[i])
+					sum[1] += uint64(row[i+1])
+					sum[2] += uint64(row[i+2])
+					sum[3] += uint64(row[i+3])
+				}
+				n += uint64(x1 - x0)
+			}
+			if n == 0 {
+				continue
+			}
+			off := dst.PixOffset(x, y)
+			for i := range sum {
+				dst.Pix[off+i] = uint8(sum[i] / n)
+			}
+		}
+	}
+	return dst
+}
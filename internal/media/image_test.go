@@ -0,0 +1,162 @@
+// internal/media/image_test.go
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// solid возвращает картинку w×h, левая половина которой left, правая — right.
+func solid(w, h int, left, right color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x < w/2 {
+				img.Set(x, y, left)
+			} else {
+				img.Set(x, y, right)
+			}
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// pngHeader возвращает PNG, у которого в IHDR записаны габариты w×h: DecodeConfig
+// читает только заголовок, так что огромную картинку не нужно кодировать.
+func pngHeader(t *testing.T, w, h int) []byte {
+	t.Helper()
+	data := encodePNG(t, image.NewGray(image.Rect(0, 0, 1, 1)))
+	ihdr := data[12 : 12+4+13] // тип чанка и его данные
+	binary.BigEndian.PutUint32(ihdr[4:], uint32(w))
+	binary.BigEndian.PutUint32(ihdr[8:], uint32(h))
+	binary.BigEndian.PutUint32(data[12+4+13:], crc32.ChecksumIEEE(ihdr))
+	return data
+}
+
+var (
+	red  = color.RGBA{R: 255, A: 255}
+	blue = color.RGBA{B: 255, A: 255}
+)
+
+func TestProcessImageRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  []byte
+		rules imageRules
+		want  string
+	}{
+		{"text", []byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"), photoRules,
+			"поддерживаются только изображения JPEG, PNG и GIF"},
+		{"truncated png", encodePNG(t, solid(200, 200, red, blue))[:40], photoRules,
+			"не удалось прочитать изображение"},
+		{"too many bytes", append(pngHeader(t, 64, 64), make([]byte, iconRules.maxBytes)...), iconRules,
+			"размер файла не должен превышать 1024 КБ"},
+		{"too small", encodePNG(t, solid(20, 40, red, blue)), iconRules,
+			"минимальный размер изображения 32x32"},
+		{"too wide", pngHeader(t, 1025, 64), iconRules,
+			"максимальный размер изображения 1024x1024"},
+		{"over the pixel budget", pngHeader(t, 8000, 6000), photoRules,
+			"изображение не должно превышать 40 Мп"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, msg := processImage(context.Background(), tt.data, tt.rules)
+			if img != nil || msg != tt.want {
+				t.Fatalf("got %v %q, want %q", img, msg, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessImageThumbnail(t *testing.T) {
+	tests := []struct {
+		name                    string
+		data                    []byte
+		rules                   imageRules
+		contentType             string
+		thumbWidth, thumbHeight int
+	}{
+		{"wide jpeg", encodeJPEG(t, solid(640, 320, red, blue)), photoRules, "image/jpeg", 320, 160},
+		{"tall png", encodePNG(t, solid(100, 400, red, blue)), photoRules, "image/png", 80, 320},
+		{"small icon is not enlarged", encodePNG(t, solid(48, 48, red, blue)), iconRules, "image/png", 48, 48},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, msg := processImage(context.Background(), tt.data, tt.rules)
+			if msg != "" {
+				t.Fatal(msg)
+			}
+			if img.ContentType != tt.contentType {
+				t.Errorf("content type %s, want %s", img.ContentType, tt.contentType)
+			}
+			thumb, format, err := image.Decode(bytes.NewReader(img.Thumb))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if "image/"+format != tt.contentType {
+				t.Errorf("thumbnail is %s, want %s", format, tt.contentType)
+			}
+			b := thumb.Bounds()
+			if b.Dx() != tt.thumbWidth || b.Dy() != tt.thumbHeight {
+				t.Fatalf("thumbnail %dx%d, want %dx%d", b.Dx(), b.Dy(), tt.thumbWidth, tt.thumbHeight)
+			}
+			// цвета половин сохраняются после усреднения
+			for x, want := range map[int]color.RGBA{0: red, b.Dx() - 1: blue} {
+				r, g, bl, _ := thumb.At(x, b.Dy()/2).RGBA()
+				got := color.RGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(bl >> 8)}
+				if diff(got.R, want.R) > 16 || diff(got.G, want.G) > 16 || diff(got.B, want.B) > 16 {
+					t.Errorf("pixel %d is %v, want about %v", x, got, want)
+				}
+			}
+		})
+	}
+}
+
+func diff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func TestProcessImageWaitsForDecodeSlot(t *testing.T) {
+	for i := 0; i < cap(decodeSlots); i++ {
+		decodeSlots <- struct{}{}
+	}
+	defer func() {
+		for i := 0; i < cap(decodeSlots); i++ {
+			<-decodeSlots
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if img, msg := processImage(ctx, encodePNG(t, solid(200, 200, red, blue)), photoRules); img != nil || msg != "запрос отменён" {
+		t.Fatalf("got %v %q, want the request to give up waiting", img, msg)
+	}
+}
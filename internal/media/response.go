@@ -0,0 +1,31 @@
+// internal/media/response.go
+package media
+
+type Response struct {
+	Success bool              `json:"success"`
+	Message string            `json:"message"`
+	Errors  map[string]string `json:"errors,omitempty"`
+}
+
+func errorResponse(msg string, errs map[string]string) Response {
+	return Response{Success: false, Message: msg, Errors: errs}
+}
+
+// Image — ссылки на загруженную картинку и её миниатюру.
+type Image struct {
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	ContentType  string `json:"content_type,omitempty"`
+	Width        int    `json:"width,omitempty"`
+	Height       int    `json:"height,omitempty"`
+}
+
+type ImageResponse struct {
+	Response
+	Image *Image `json:"image,omitempty"`
+}
+
+type ImagesResponse struct {
+	Response
+	Images []*Image `json:"images"`
+}
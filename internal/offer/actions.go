@@ -0,0 +1,106 @@
+// internal/offer/actions.go
+package offer
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	commonpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/common/v1"
+	offerpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/offer/v1"
+	orderpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/order/v1"
+)
+
+// roleMaster — роль пользователя, который может предлагать офферы.
+const roleMaster = "master"
+
+// authorizeCreate проверяет, что оффер создаёт мастер и от своего имени.
+func authorizeCreate(userID, role string, req createOfferRequest) *ProtocolError {
+	if role != roleMaster || req.MasterId != userID {
+		return errForbidden
+	}
+	return nil
+}
+
+// createOffer создаёт оффер и оповещает подписчиков заказа. Общая часть
+// WebSocket и REST; запрос уже проверен.
+func createOffer(ctx context.Context, client offerpbv1.OfferServiceClient, hub *Hub, req createOfferRequest) (*commonpbv1.OfferData, error) {
+	resp, err := client.CreateOffer(ctx, &offerpbv1.CreateOfferRequest{
+		OrderId:  req.OrderId,
+		MasterId: req.MasterId,
+		Price:    req.Price,
+	})
+	if err != nil {
+		return nil, err
+	}
+	hub.Broadcast(req.OrderId, Event{Action: typeOfferCreated, Offer: resp.Offer})
+	return resp.Offer, nil
+}
+
+// authorizeStatusChange проверяет, что пользователь может перевести оффер
+// offerID заказа orderID в статус to. OfferService не ищет оффер по id,
+// поэтому оффер ищется среди офферов заказа, а заказ нужен, чтобы узнать
+// его клиента. Возвращает ошибку для клиента или nil.
+func authorizeStatusChange(
+	ctx context.Context,
+	client offerpbv1.OfferServiceClient,
+	orderClient orderpbv1.OrderServiceClient,
+	orderID, offerID, userID, role, to string,
+) *ProtocolError {
+	orderResp, err := orderClient.GetOrderById(ctx, &orderpbv1.GetOrderByIdRequest{Id: orderID})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return errOrderNotFound
+		}
+		return errInternal
+	}
+	offersResp, err := client.GetMyOrderOffers(ctx, &offerpbv1.GetMyOrderOffersRequest{OrderId: orderID})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return errOfferNotFound
+		}
+		return errInternal
+	}
+	var current *commonpbv1.OfferData
+	for _, o := range offersResp.Offers {
+		if o.GetId() == offerID {
+			current = o
+			break
+		}
+	}
+	if current == nil {
+		return errOfferNotFound
+	}
+
+	actor := offerActor(orderResp.Order, current, userID, role)
+	if actor == "" {
+		return errForbidden
+	}
+	if !canTransition(current.GetStatus(), to) {
+		return errInvalidTransition
+	}
+	if !canPerform(current.GetStatus(), to, actor) {
+		return errForbidden
+	}
+	return nil
+}
+
+// updateOffer меняет статус оффера id заказа orderID и оповещает подписчиков
+// заказа: подписки ведутся по id заказа, не оффера. Если сервис не вернул
+// заказ оффера, событие уходит подписчикам orderID — оффер уже найден среди
+// офферов этого заказа при проверке прав.
+func updateOffer(ctx context.Context, client offerpbv1.OfferServiceClient, hub *Hub, orderID, id, status string) (*commonpbv1.OfferData, error) {
+	resp, err := client.UpdateOffer(ctx, &offerpbv1.UpdateOfferRequest{
+		Id:     id,
+		Status: status,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if id := resp.Offer.GetOrder().GetId(); id != "" {
+		orderID = id
+	}
+	hub.Broadcast(orderID, Event{Action: typeOfferUpdated, Offer: resp.Offer})
+	return resp.Offer, nil
+}
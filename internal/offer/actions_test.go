@@ -0,0 +1,240 @@
+// internal/offer/actions_test.go
+package offer
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	commonpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/common/v1"
+	offerpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/offer/v1"
+)
+
+// fakeOffers хранит офферы в памяти. Как и настоящий сервис, отдаёт
+// оффер вместе с его заказом.
+type fakeOffers struct {
+	offerpbv1.OfferServiceClient
+	mu     sync.Mutex
+	offers map[string]*commonpbv1.OfferData
+}
+
+func newFakeOffers() *fakeOffers {
+	return &fakeOffers{offers: make(map[string]*commonpbv1.OfferData)}
+}
+
+func (f *fakeOffers) CreateOffer(_ context.Context, req *offerpbv1.CreateOfferRequest, _ ...grpc.CallOption) (*offerpbv1.CreateOfferResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	o := &commonpbv1.OfferData{
+		Id:     uuid.NewString(),
+		Master: &commonpbv1.UserData{Id: req.MasterId},
+		Order:  &commonpbv1.OrderData{Id: req.OrderId},
+		Price:  req.Price,
+		Status: "pending",
+	}
+	f.offers[o.Id] = o
+	return &offerpbv1.CreateOfferResponse{Offer: o}, nil
+}
+
+func (f *fakeOffers) GetMyOrderOffers(_ context.Context, req *offerpbv1.GetMyOrderOffersRequest, _ ...grpc.CallOption) (*offerpbv1.GetMyOrderOffersResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	resp := &offerpbv1.GetMyOrderOffersResponse{}
+	for _, o := range f.offers {
+		if o.GetOrder().GetId() == req.OrderId {
+			resp.Offers = append(resp.Offers, o)
+		}
+	}
+	return resp, nil
+}
+
+func (f *fakeOffers) UpdateOffer(_ context.Context, req *offerpbv1.UpdateOfferRequest, _ ...grpc.CallOption) (*offerpbv1.UpdateOfferResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	o, ok := f.offers[req.Id]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "оффер не найден")
+	}
+	o = &commonpbv1.OfferData{Id: o.Id, Master: o.Master, Order: o.Order, Price: o.Price, Status: req.Status}
+	f.offers[o.Id] = o
+	return &offerpbv1.UpdateOfferResponse{Offer: o}, nil
+}
+
+// offerEvent разбирает событие offers.v1 из конверта.
+func offerEvent(t *testing.T, env Envelope) eventPayload {
+	t.Helper()
+	var p eventPayload
+	if err := json.Unmarshal(env.Payload, &p); err != nil {
+		t.Fatalf("event %s: %v", env.Type, err)
+	}
+	return p
+}
+
+// nextEvent возвращает первое событие из events, а если их нет — читает из conn.
+func nextEvent(t *testing.T, conn *websocket.Conn, events *[]Envelope) Envelope {
+	t.Helper()
+	if len(*events) > 0 {
+		env := (*events)[0]
+		*events = (*events)[1:]
+		return env
+	}
+	var env Envelope
+	if err := conn.ReadJSON(&env); err != nil {
+		t.Fatalf("read event: %v", err)
+	}
+	return env
+}
+
+func TestOrderSubscribersGetCreatedAndUpdatedOffers(t *testing.T) {
+	hub := NewHub()
+	srv := newWsServer(t, hub, newFakeOffers())
+
+	client, err := dialWs(srv, "client:"+testClientID)
+	if err != nil {
+		t.Fatalf("dial client: %v", err)
+	}
+	defer client.Close()
+	master, err := dialWs(srv, "master:"+testMasterID)
+	if err != nil {
+		t.Fatalf("dial master: %v", err)
+	}
+	defer master.Close()
+
+	// подписчик другого заказа событий не получает
+	other, err := dialWs(srv, "client:"+testClientID)
+	if err != nil {
+		t.Fatalf("dial other: %v", err)
+	}
+	defer other.Close()
+
+	if _, err := call(client, typeSubscribe, "s1", subscribePayload{OrderId: testOrderID}, nil); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	if _, err := call(other, typeSubscribe, "s1", subscribePayload{OrderId: uuid.NewString()}, nil); err != nil {
+		t.Fatalf("subscribe other: %v", err)
+	}
+
+	reply, err := call(master, typeCreateOffer, "c1", createOfferRequest{
+		OrderId:  testOrderID,
+		MasterId: testMasterID,
+		Price:    1500,
+	}, nil)
+	if err != nil {
+		t.Fatalf("createOffer: %v", err)
+	}
+	var created offerPayload
+	if err := json.Unmarshal(reply.Payload, &created); err != nil {
+		t.Fatalf("createOffer reply: %v", err)
+	}
+
+	var events []Envelope
+	ev := nextEvent(t, client, &events)
+	if ev.Type != typeOfferCreated {
+		t.Fatalf("got %s, want %s", ev.Type, typeOfferCreated)
+	}
+	if p := offerEvent(t, ev); p.OrderId != testOrderID || p.Offer.GetId() != created.Offer.GetId() || p.Seq != 1 {
+		t.Fatalf("offerCreated = %+v, want offer %s of order %s with seq 1", p, created.Offer.GetId(), testOrderID)
+	}
+
+	// событие об изменении должно уйти подписчикам заказа, а не оффера
+	if _, err := call(client, typeUpdateOffer, "u1", updateOfferRequest{
+		OfferId: created.Offer.GetId(),
+		OrderId: testOrderID,
+		Status:  StatusAccepted,
+	}, &events); err != nil {
+		t.Fatalf("updateOffer: %v", err)
+	}
+	ev = nextEvent(t, client, &events)
+	if ev.Type != typeOfferUpdated {
+		t.Fatalf("got %s, want %s", ev.Type, typeOfferUpdated)
+	}
+	if p := offerEvent(t, ev); p.OrderId != testOrderID || p.Offer.GetStatus() != StatusAccepted || p.Seq != 2 {
+		t.Fatalf("offerUpdated = %+v, want accepted offer of order %s with seq 2", p, testOrderID)
+	}
+
+	// у подписчика другого заказа первым приходит ответ на следующий запрос
+	var stray []Envelope
+	if _, err := call(other, typeUnsubscribe, "u1", orderPayload{OrderId: testOrderID}, &stray); err != nil {
+		t.Fatalf("unsubscribe other: %v", err)
+	}
+	if len(stray) != 0 {
+		t.Fatalf("subscriber of another order got %d events", len(stray))
+	}
+}
+
+func TestUpdateOfferDeniesForeignUser(t *testing.T) {
+	offers := newStatusOffers()
+	srv := newWsServer(t, NewHub(), offers)
+
+	for _, token := range []string{"client:" + testOtherUserID, "master:" + testOtherUserID, "master:" + testMasterID} {
+		conn, err := dialWs(srv, token)
+		if err != nil {
+			t.Fatalf("dial %s: %v", token, err)
+		}
+		reply, err := call(conn, typeUpdateOffer, "u1", updateOfferRequest{
+			OfferId: testPendingOfferID,
+			OrderId: testOrderID,
+			Status:  StatusAccepted,
+		}, nil)
+		conn.Close()
+		if err == nil || reply.Error.Code != errForbidden.Code {
+			t.Fatalf("%s: updateOffer = %v, want %s", token, err, errForbidden.Code)
+		}
+	}
+	offers.mu.Lock()
+	defer offers.mu.Unlock()
+	if st := offers.offers[testPendingOfferID].GetStatus(); st != StatusPending {
+		t.Fatalf("offer status %q after denied updates, want %q", st, StatusPending)
+	}
+}
+
+// orderlessOffers отвечает на UpdateOffer оффером без заказа.
+type orderlessOffers struct {
+	*fakeOffers
+}
+
+func (f orderlessOffers) UpdateOffer(ctx context.Context, req *offerpbv1.UpdateOfferRequest, opts ...grpc.CallOption) (*offerpbv1.UpdateOfferResponse, error) {
+	resp, err := f.fakeOffers.UpdateOffer(ctx, req, opts...)
+	if err != nil {
+		return nil, err
+	}
+	o := proto.Clone(resp.Offer).(*commonpbv1.OfferData)
+	o.Order = nil
+	return &offerpbv1.UpdateOfferResponse{Offer: o}, nil
+}
+
+func TestUpdateOfferEventGoesToOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		client offerpbv1.OfferServiceClient
+	}{
+		{"service returns the order", newStatusOffers()},
+		{"service omits the order", orderlessOffers{newStatusOffers()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := NewHub()
+			events, cancel := hub.Listen(testOrderID, 1)
+			defer cancel()
+			if _, err := updateOffer(context.Background(), tt.client, hub, testOrderID, testPendingOfferID, StatusAccepted); err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case ev := <-events:
+				if ev.Action != typeOfferUpdated || ev.Offer.GetId() != testPendingOfferID {
+					t.Fatalf("got %s of offer %s", ev.Action, ev.Offer.GetId())
+				}
+			default:
+				t.Fatal("order subscribers got no event")
+			}
+		})
+	}
+}
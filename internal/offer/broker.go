@@ -0,0 +1,89 @@
+// internal/offer/broker.go
+package offer
+
+import (
+	"context"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Broker передаёт события хаба между экземплярами шлюза. Брокер только
+// доставляет байты: кодирование и отсев собственных сообщений делает Hub.
+type Broker interface {
+	// Publish отправляет сообщение всем экземплярам, включая текущий.
+	Publish(ctx context.Context, msg []byte) error
+	// Subscribe передаёт полученные сообщения в handle, пока не отменён ctx
+	// или не оборвалось соединение.
+	Subscribe(ctx context.Context, handle func(msg []byte)) error
+}
+
+// MemoryBroker — брокер внутри одного процесса. Годится для одной реплики
+// и для связки нескольких хабов в тестах.
+type MemoryBroker struct {
+	mu       sync.RWMutex
+	handlers map[*func([]byte)]bool
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{handlers: make(map[*func([]byte)]bool)}
+}
+
+func (b *MemoryBroker) Publish(_ context.Context, msg []byte) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for h := range b.handlers {
+		(*h)(msg)
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(ctx context.Context, handle func(msg []byte)) error {
+	h := &handle
+	b.mu.Lock()
+	b.handlers[h] = true
+	b.mu.Unlock()
+
+	<-ctx.Done()
+
+	b.mu.Lock()
+	delete(b.handlers, h)
+	b.mu.Unlock()
+	return ctx.Err()
+}
+
+// RedisBroker рассылает события через Redis Pub/Sub. Подойдёт и любой
+// сервер, совместимый с протоколом Redis (KeyDB, Dragonfly и т.п.).
+type RedisBroker struct {
+	client  *redis.Client
+	channel string
+}
+
+func NewRedisBroker(client *redis.Client, channel string) *RedisBroker {
+	return &RedisBroker{client: client, channel: channel}
+}
+
+func (b *RedisBroker) Publish(ctx context.Context, msg []byte) error {
+	return b.client.Publish(ctx, b.channel, msg).Err()
+}
+
+func (b *RedisBroker) Subscribe(ctx context.Context, handle func(msg []byte)) error {
+	ps := b.client.Subscribe(ctx, b.channel)
+	defer ps.Close()
+	// дождаться подтверждения подписки, чтобы ошибка соединения вернулась сразу
+	if _, err := ps.Receive(ctx); err != nil {
+		return err
+	}
+	ch := ps.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case m, ok := <-ch:
+			if !ok {
+				return redis.ErrClosed
+			}
+			handle([]byte(m.Payload))
+		}
+	}
+}
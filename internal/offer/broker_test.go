@@ -0,0 +1,352 @@
+// internal/offer/broker_test.go
+package offer
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	commonpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/common/v1"
+)
+
+// fakeRedis — сервер протокола Redis (RESP2), который умеет только Pub/Sub:
+// SUBSCRIBE, UNSUBSCRIBE, PUBLISH и PING. На остальные команды, в том числе
+// HELLO и CLIENT SETINFO, отвечает ошибкой, и go-redis остаётся на RESP2.
+type fakeRedis struct {
+	ln   net.Listener
+	mu   sync.Mutex
+	subs map[string]map[*redisConn]bool
+}
+
+type redisConn struct {
+	mu sync.Mutex
+	w  *bufio.Writer
+}
+
+func (c *redisConn) write(parts ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, p := range parts {
+		c.w.WriteString(p)
+	}
+	c.w.Flush()
+}
+
+func bulk(s string) string {
+	return "$" + strconv.Itoa(len(s)) + "\r\n" + s + "\r\n"
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &fakeRedis{ln: ln, subs: make(map[string]map[*redisConn]bool)}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			nc, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(nc)
+		}
+	}()
+	return s
+}
+
+// subscribers — число подписок на channel.
+func (s *fakeRedis) subscribers(channel string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.subs[channel])
+}
+
+func (s *fakeRedis) serve(nc net.Conn) {
+	defer nc.Close()
+	r := bufio.NewReader(nc)
+	c := &redisConn{w: bufio.NewWriter(nc)}
+	channels := map[string]bool{}
+	defer func() {
+		s.mu.Lock()
+		for ch := range channels {
+			delete(s.subs[ch], c)
+		}
+		s.mu.Unlock()
+	}()
+
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		switch strings.ToUpper(args[0]) {
+		case "SUBSCRIBE":
+			for _, ch := range args[1:] {
+				s.mu.Lock()
+				if s.subs[ch] == nil {
+					s.subs[ch] = make(map[*redisConn]bool)
+				}
+				s.subs[ch][c] = true
+				s.mu.Unlock()
+				channels[ch] = true
+				c.write("*3\r\n", bulk("subscribe"), bulk(ch), ":"+strconv.Itoa(len(channels))+"\r\n")
+			}
+		case "UNSUBSCRIBE":
+			for _, ch := range args[1:] {
+				s.mu.Lock()
+				delete(s.subs[ch], c)
+				s.mu.Unlock()
+				delete(channels, ch)
+				c.write("*3\r\n", bulk("unsubscribe"), bulk(ch), ":"+strconv.Itoa(len(channels))+"\r\n")
+			}
+		case "PUBLISH":
+			if len(args) != 3 {
+				c.write("-ERR wrong number of arguments\r\n")
+				continue
+			}
+			// как и Redis, сообщение пишется всем подписчикам до ответа издателю
+			s.mu.Lock()
+			n := 0
+			for sub := range s.subs[args[1]] {
+				sub.write("*3\r\n", bulk("message"), bulk(args[1]), bulk(args[2]))
+				n++
+			}
+			s.mu.Unlock()
+			c.write(":" + strconv.Itoa(n) + "\r\n")
+		case "PING":
+			if len(channels) > 0 {
+				c.write("*2\r\n", bulk("pong"), bulk(""))
+			} else {
+				c.write("+PONG\r\n")
+			}
+		default:
+			c.write("-ERR unknown command '" + args[0] + "'\r\n")
+		}
+	}
+}
+
+// readCommand читает команду — массив bulk-строк.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, fmt.Errorf("unexpected %q", line)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("bad array header %q", line)
+	}
+	args := make([]string, n)
+	for i := range args {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, fmt.Errorf("bad bulk header %q", line)
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+// waitFor ждёт выполнения условия, например подписки хабов на брокер.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// recvOffer возвращает id оффера из следующего события канала.
+func recvOffer(t *testing.T, name string, ch <-chan Event) string {
+	t.Helper()
+	select {
+	case ev := <-ch:
+		return ev.Offer.GetId()
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%s: no event", name)
+		return ""
+	}
+}
+
+// testFanOut связывает два хаба через брокер и проверяет, что событие,
+// разосланное одним, получают подписчики обоих, причём ровно один раз.
+// ready сообщает, что оба хаба подписались на брокер.
+func testFanOut(t *testing.T, a, b *Hub, ready func() bool) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+	for _, h := range []*Hub{a, b} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.Run(ctx)
+		}()
+	}
+	waitFor(t, "broker subscriptions", ready)
+
+	const orderID = "o1"
+	onA, cancelA := a.Listen(orderID, 8)
+	defer cancelA()
+	onB, cancelB := b.Listen(orderID, 8)
+	defer cancelB()
+
+	a.Broadcast(orderID, Event{Action: typeOfferCreated, Offer: &commonpbv1.OfferData{Id: "from-a"}})
+	if got := recvOffer(t, "b", onB); got != "from-a" {
+		t.Fatalf("b got %q, want from-a", got)
+	}
+	// второе событие идёт через брокер после первого: эхо первого пришло
+	// бы раньше него
+	b.Broadcast(orderID, Event{Action: typeOfferCreated, Offer: &commonpbv1.OfferData{Id: "from-b"}})
+	for name, ch := range map[string]<-chan Event{"a": onA, "b": onB} {
+		want := []string{"from-a", "from-b"}
+		if name == "b" {
+			want = want[1:]
+		}
+		for _, id := range want {
+			if got := recvOffer(t, name, ch); got != id {
+				t.Fatalf("%s got %q, want %q", name, got, id)
+			}
+		}
+	}
+	// а это событие по заказу, за которым никто не следит
+	a.Broadcast("o2", Event{Action: typeOfferCreated, Offer: &commonpbv1.OfferData{Id: "other"}})
+	b.Broadcast(orderID, Event{Action: typeOfferCreated, Offer: &commonpbv1.OfferData{Id: "last"}})
+	for name, ch := range map[string]<-chan Event{"a": onA, "b": onB} {
+		if got := recvOffer(t, name, ch); got != "last" {
+			t.Fatalf("%s got %q, want last", name, got)
+		}
+	}
+}
+
+func TestHubFanOutMemoryBroker(t *testing.T) {
+	broker := NewMemoryBroker()
+	a, b := NewClusterHub("a", broker), NewClusterHub("b", broker)
+	testFanOut(t, a, b, func() bool {
+		broker.mu.RLock()
+		defer broker.mu.RUnlock()
+		return len(broker.handlers) == 2
+	})
+}
+
+func TestHubFanOutRedisBroker(t *testing.T) {
+	srv := newFakeRedis(t)
+	const channel = "offers"
+	hub := func(id string) *Hub {
+		client := redis.NewClient(&redis.Options{Addr: srv.ln.Addr().String(), Protocol: 2})
+		t.Cleanup(func() { client.Close() })
+		return NewClusterHub(id, NewRedisBroker(client, channel))
+	}
+	testFanOut(t, hub("a"), hub("b"), func() bool {
+		return srv.subscribers(channel) == 2
+	})
+}
+
+// stalledBroker не отвечает на Publish, пока не закрыт release, и
+// запоминает отправленные сообщения.
+type stalledBroker struct {
+	release chan struct{}
+	mu      sync.Mutex
+	sent    [][]byte
+}
+
+func (b *stalledBroker) Publish(ctx context.Context, msg []byte) error {
+	select {
+	case <-b.release:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sent = append(b.sent, msg)
+	return nil
+}
+
+func (b *stalledBroker) Subscribe(ctx context.Context, _ func([]byte)) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (b *stalledBroker) count() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.sent)
+}
+
+// Зависший брокер не задерживает Broadcast: событие сразу доходит до
+// локальных подписчиков, а в брокер уходит из очереди, лишнее отбрасывается.
+func TestBroadcastDoesNotWaitForBroker(t *testing.T) {
+	broker := &stalledBroker{release: make(chan struct{})}
+	h := NewClusterHub("a", broker)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go h.Run(ctx)
+
+	const total = publishQueueSize + 50
+	events, stop := h.Listen("o1", total)
+	defer stop()
+	start := time.Now()
+	for i := 0; i < total; i++ {
+		h.Broadcast("o1", Event{Action: typeOfferCreated, Offer: &commonpbv1.OfferData{Id: strconv.Itoa(i)}})
+	}
+	if elapsed := time.Since(start); elapsed > publishTimeout {
+		t.Fatalf("Broadcast waited for the broker: %s", elapsed)
+	}
+	for i := 0; i < total; i++ {
+		if got := recvOffer(t, "local", events); got != strconv.Itoa(i) {
+			t.Fatalf("local event %d is %q", i, got)
+		}
+	}
+
+	// брокер ожил: уходит то, что поместилось в очередь (и одно, взятое
+	// из неё до зависания), в исходном порядке
+	close(broker.release)
+	waitFor(t, "queued events", func() bool { return broker.count() >= publishQueueSize })
+	time.Sleep(20 * time.Millisecond)
+	sent := broker.count()
+	if sent > publishQueueSize+1 {
+		t.Fatalf("broker got %d events, queue holds %d", sent, publishQueueSize)
+	}
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+	last := -1
+	for i, raw := range broker.sent {
+		var m brokerMessage
+		var ev Event
+		if json.Unmarshal(raw, &m) != nil || json.Unmarshal(m.Event, &ev) != nil {
+			t.Fatalf("message %d: %s", i, raw)
+		}
+		id, _ := strconv.Atoi(ev.Offer.GetId())
+		if id <= last {
+			t.Fatalf("message %d carries offer %d after %d", i, id, last)
+		}
+		last = id
+	}
+}
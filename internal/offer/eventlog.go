@@ -0,0 +1,87 @@
+// internal/offer/eventlog.go
+package offer
+
+import (
+	"fmt"
+	"time"
+
+	commonpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/common/v1"
+	"github.com/google/uuid"
+)
+
+const (
+	// eventLogSize — сколько последних событий заказа хранится для досылки.
+	eventLogSize = 100
+	// eventLogTTL — сколько журнал заказа живёт после ухода последнего
+	// подписчика: за это время переподключившийся клиент получит пропущенное.
+	eventLogTTL = 10 * time.Minute
+)
+
+// Position — место в журнале событий заказа. Номера событий растут внутри
+// эпохи; эпоха меняется, когда журнал создаётся заново (перезапуск,
+// другая реплика, истёкший журнал), и тогда старые номера ничего не значат.
+type Position struct {
+	Epoch string
+	Seq   uint64
+}
+
+// eventLog — журнал последних событий заказа на этом экземпляре и версии
+// офферов, по которым Hub.Sync находит изменения.
+type eventLog struct {
+	epoch  string
+	seq    uint64
+	events []Event
+	// versions — последние разосланные версии офферов; seeded — снял ли
+	// Sync начальное состояние заказа
+	versions map[string]string
+	seeded   bool
+	// idleSince — когда ушёл последний подписчик; нулевое, пока за заказом следят
+	idleSince time.Time
+}
+
+func newEventLog() *eventLog {
+	return &eventLog{
+		epoch:    uuid.NewString(),
+		versions: make(map[string]string),
+	}
+}
+
+// append присваивает событию следующий номер и запоминает его.
+func (l *eventLog) append(ev Event) Event {
+	l.seq++
+	ev.Seq = l.seq
+	if len(l.events) == eventLogSize {
+		copy(l.events, l.events[1:])
+		l.events = l.events[:len(l.events)-1]
+	}
+	l.events = append(l.events, ev)
+	if ev.Offer != nil {
+		l.versions[ev.Offer.GetId()] = offerVersion(ev.Offer)
+	}
+	return ev
+}
+
+// since возвращает события после pos. false означает, что досылка
+// невозможна: журнал другой эпохи или нужные события уже вытеснены.
+func (l *eventLog) since(pos Position) ([]Event, bool) {
+	if pos.Epoch != l.epoch || pos.Seq > l.seq {
+		return nil, false
+	}
+	if pos.Seq == l.seq {
+		return nil, true
+	}
+	first := l.events[0].Seq
+	if pos.Seq+1 < first {
+		return nil, false
+	}
+	return append([]Event(nil), l.events[pos.Seq+1-first:]...), true
+}
+
+func (l *eventLog) position() Position {
+	return Position{Epoch: l.epoch, Seq: l.seq}
+}
+
+// offerVersion — то, по чему Sync отличает изменившийся оффер.
+func offerVersion(o *commonpbv1.OfferData) string {
+	return fmt.Sprintf("%s|%g|%s", o.GetStatus(), o.GetPrice(), o.GetUpdatedAt())
+}
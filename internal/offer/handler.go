@@ -0,0 +1,272 @@
+// internal/offer/handler.go
+package offer
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/auth"
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/idempotency"
+	commonpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/common/v1"
+	offerpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/offer/v1"
+	orderpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/order/v1"
+	"github.com/Ostap00034/course-work-backend-auth-service/util/jwt"
+)
+
+// CreateOfferHandler создаёт оффер; подписчики заказа получают offerCreated,
+// как при createOffer через WebSocket. Создать оффер может только мастер
+// и только от своего имени.
+func CreateOfferHandler(client offerpbv1.OfferServiceClient, hub *Hub) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := auth.CurrentUser(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, errorResponse("требуется авторизация", nil))
+			return
+		}
+		var req createOfferRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse("ошибка валидации", offerValidationErrors(err)))
+			return
+		}
+		if authorizeCreate(claims.UserID, claims.Role, req) != nil {
+			c.JSON(http.StatusForbidden, errorResponse("оффер может создать только мастер от своего имени", nil))
+			return
+		}
+
+		created, err := createOffer(c.Request.Context(), client, hub, req)
+		if err != nil {
+			writeRPCError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, OfferResponse{
+			Response: Response{Success: true, Message: "успешно"},
+			Offer:    created,
+		})
+	}
+}
+
+// GetOrderOffersHandler отдаёт офферы заказа тем, кто может за ним следить.
+func GetOrderOffersHandler(client offerpbv1.OfferServiceClient, orderClient orderpbv1.OrderServiceClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orderID := c.Param("id")
+		offers, ok := loadOrderOffers(c, client, orderClient, orderID)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, OffersResponse{
+			Response: Response{Success: true, Message: "успешно"},
+			Offers:   offers,
+		})
+	}
+}
+
+// GetOfferHandler отдаёт оффер по id. OfferService не ищет оффер по id,
+// поэтому в параметре order_id передаётся его заказ.
+func GetOfferHandler(client offerpbv1.OfferServiceClient, orderClient orderpbv1.OrderServiceClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, req, ok := bindOfferRequest(c)
+		if !ok {
+			return
+		}
+		offers, ok := loadOrderOffers(c, client, orderClient, req.OrderId)
+		if !ok {
+			return
+		}
+		for _, o := range offers {
+			if o.GetId() == id {
+				c.JSON(http.StatusOK, OfferResponse{
+					Response: Response{Success: true, Message: "успешно"},
+					Offer:    o,
+				})
+				return
+			}
+		}
+		c.JSON(http.StatusNotFound, errorResponse("оффер не найден", nil))
+	}
+}
+
+// UpdateOfferHandler меняет статус оффера; подписчики заказа получают offerUpdated.
+// Принять или отклонить оффер может клиент заказа, отозвать — его мастер;
+// администратор может всё. Заказ оффера передаётся в параметре order_id.
+func UpdateOfferHandler(client offerpbv1.OfferServiceClient, orderClient orderpbv1.OrderServiceClient, hub *Hub) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := auth.CurrentUser(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, errorResponse("требуется авторизация", nil))
+			return
+		}
+		id, query, ok := bindOfferRequest(c)
+		if !ok {
+			return
+		}
+		var req patchOfferRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse("ошибка валидации", offerValidationErrors(err)))
+			return
+		}
+		setOfferStatus(c, client, orderClient, hub, claims, query.OrderId, id, req.Status)
+	}
+}
+
+// AcceptOfferHandler принимает оффер: статус становится accepted. Принять
+// оффер может клиент заказа или администратор.
+func AcceptOfferHandler(client offerpbv1.OfferServiceClient, orderClient orderpbv1.OrderServiceClient, hub *Hub) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := auth.CurrentUser(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, errorResponse("требуется авторизация", nil))
+			return
+		}
+		id, query, ok := bindOfferRequest(c)
+		if !ok {
+			return
+		}
+		setOfferStatus(c, client, orderClient, hub, claims, query.OrderId, id, StatusAccepted)
+	}
+}
+
+// bindOfferRequest разбирает id оффера из пути и его заказ из параметров
+// запроса. При ошибке ответ уже записан.
+func bindOfferRequest(c *gin.Context) (string, offerRequest, bool) {
+	var req offerRequest
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse("неверный формат id", nil))
+		return "", req, false
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse("некорректные параметры запроса", offerValidationErrors(err)))
+		return "", req, false
+	}
+	return id, req, true
+}
+
+func setOfferStatus(
+	c *gin.Context,
+	client offerpbv1.OfferServiceClient,
+	orderClient orderpbv1.OrderServiceClient,
+	hub *Hub,
+	claims *jwt.Claims,
+	orderID, id, to string,
+) {
+	perr := authorizeStatusChange(c.Request.Context(), client, orderClient, orderID, id, claims.UserID, claims.Role, to)
+	if !writeStatusError(c, perr) {
+		return
+	}
+	updated, err := updateOffer(c.Request.Context(), client, hub, orderID, id, to)
+	if err != nil {
+		writeRPCError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, OfferResponse{
+		Response: Response{Success: true, Message: "успешно"},
+		Offer:    updated,
+	})
+}
+
+// loadOrderOffers проверяет доступ к заказу и загружает его офферы.
+// При ошибке ответ уже записан.
+func loadOrderOffers(
+	c *gin.Context,
+	client offerpbv1.OfferServiceClient,
+	orderClient orderpbv1.OrderServiceClient,
+	orderID string,
+) ([]*commonpbv1.OfferData, bool) {
+	claims, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse("требуется авторизация", nil))
+		return nil, false
+	}
+	if !writeFollowError(c, authorizeFollow(c.Request.Context(), orderClient, orderID, claims.UserID, claims.Role)) {
+		return nil, false
+	}
+	resp, err := client.GetMyOrderOffers(c.Request.Context(), &offerpbv1.GetMyOrderOffersRequest{OrderId: orderID})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return []*commonpbv1.OfferData{}, true
+		}
+		writeRPCError(c, err)
+		return nil, false
+	}
+	if resp.Offers == nil {
+		return []*commonpbv1.OfferData{}, true
+	}
+	return resp.Offers, true
+}
+
+// writeFollowError записывает ответ на отказ authorizeFollow. Возвращает
+// true, если доступ есть и ответ не записан.
+func writeFollowError(c *gin.Context, perr *ProtocolError) bool {
+	switch perr {
+	case nil:
+		return true
+	case errOrderNotFound:
+		c.JSON(http.StatusNotFound, errorResponse("заказ не найден", nil))
+	case errForbidden:
+		c.JSON(http.StatusForbidden, errorResponse("доступ запрещён", nil))
+	default:
+		c.JSON(http.StatusInternalServerError, errorResponse("внутренняя ошибка сервера", nil))
+	}
+	return false
+}
+
+// writeStatusError записывает ответ на отказ authorizeStatusChange.
+// Возвращает true, если изменение разрешено и ответ не записан.
+func writeStatusError(c *gin.Context, perr *ProtocolError) bool {
+	switch perr {
+	case errOfferNotFound:
+		c.JSON(http.StatusNotFound, errorResponse("оффер не найден", nil))
+	case errInvalidTransition:
+		c.JSON(http.StatusConflict, errorResponse("недопустимый переход статуса оффера", nil))
+	case errForbidden:
+		c.JSON(http.StatusForbidden, errorResponse("недостаточно прав для изменения статуса", nil))
+	default:
+		return writeFollowError(c, perr)
+	}
+	return false
+}
+
+// writeRPCError переводит ошибку OfferService в HTTP-ответ с сообщением сервиса.
+func writeRPCError(c *gin.Context, err error) {
+	st, ok := status.FromError(err)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, errorResponse("внутренняя ошибка сервера", nil))
+		return
+	}
+	code := http.StatusInternalServerError
+	switch st.Code() {
+	case codes.InvalidArgument:
+		code = http.StatusBadRequest
+	case codes.NotFound:
+		code = http.StatusNotFound
+	case codes.Unauthenticated:
+		code = http.StatusUnauthorized
+	case codes.PermissionDenied:
+		code = http.StatusForbidden
+	case codes.AlreadyExists, codes.FailedPrecondition, codes.Aborted:
+		code = http.StatusConflict
+	}
+	c.JSON(code, errorResponse(st.Message(), nil))
+}
+
+// RegisterHandlers вешает REST-маршруты офферов. Действия те же, что у
+// WebSocket: созданные и изменённые офферы рассылаются подписчикам через hub.
+func RegisterHandlers(
+	r gin.IRouter,
+	hub *Hub,
+	client offerpbv1.OfferServiceClient,
+	orderClient orderpbv1.OrderServiceClient,
+	idem *idempotency.Store,
+) {
+	r.POST("/offers", idempotency.Middleware(idem), CreateOfferHandler(client, hub))
+	r.GET("/offers/:id", GetOfferHandler(client, orderClient))
+	r.PATCH("/offers/:id", UpdateOfferHandler(client, orderClient, hub))
+	r.POST("/offers/:id/accept", AcceptOfferHandler(client, orderClient, hub))
+	r.GET("/orders/:id/offers", GetOrderOffersHandler(client, orderClient))
+	r.GET("/orders/:id/offers/stream", StreamOffersHandler(hub, orderClient))
+}
@@ -0,0 +1,98 @@
+// internal/offer/handler_test.go
+package offer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/idempotency"
+	"github.com/Ostap00034/course-work-backend-auth-service/util/jwt"
+)
+
+// newRestRouter вешает REST-маршруты офферов с поддельными сервисами.
+func newRestRouter(hub *Hub, offers *fakeOffers) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterHandlers(r, hub, offers, fakeOrders{}, idempotency.NewStore(time.Minute))
+	return r
+}
+
+// serve выполняет запрос от имени пользователя с ролью role; пустой userID —
+// запрос без cookie.
+func serve(t *testing.T, r *gin.Engine, method, target, body, userID, role string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if userID != "" {
+		token, err := jwt.GenerateToken(jwt.NewClaims(userID, role, time.Now().Add(time.Hour)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestOfferStatusEndpoints(t *testing.T) {
+	pending := "/offers/" + testPendingOfferID
+	query := "?order_id=" + testOrderID
+	tests := []struct {
+		name, method, target, body string
+		userID, role               string
+		code                       int
+	}{
+		{"anonymous", "POST", pending + "/accept" + query, "", "", "", http.StatusUnauthorized},
+		{"id is not a uuid", "POST", "/offers/42/accept" + query, "", testClientID, "client", http.StatusBadRequest},
+		{"no order_id", "POST", pending + "/accept", "", testClientID, "client", http.StatusBadRequest},
+		{"unknown status", "PATCH", pending + query, `{"status":"paid"}`, testClientID, "client", http.StatusBadRequest},
+		{"foreign client accepts", "POST", pending + "/accept" + query, "", testOtherUserID, "client", http.StatusForbidden},
+		{"foreign master rejects", "PATCH", pending + query, `{"status":"rejected"}`, testOtherUserID, "master", http.StatusForbidden},
+		{"master accepts own offer", "POST", pending + "/accept" + query, "", testMasterID, "master", http.StatusForbidden},
+		{"accepted is final", "PATCH", "/offers/" + testAcceptedOfferID + query, `{"status":"rejected"}`, testClientID, "client", http.StatusConflict},
+		{"unknown offer", "POST", "/offers/" + testOtherUserID + "/accept" + query, "", testClientID, "client", http.StatusNotFound},
+	}
+	r := newRestRouter(NewHub(), newStatusOffers())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := serve(t, r, tt.method, tt.target, tt.body, tt.userID, tt.role); w.Code != tt.code {
+				t.Fatalf("got %d %s, want %d", w.Code, w.Body, tt.code)
+			}
+		})
+	}
+}
+
+func TestAcceptOfferByOrderClient(t *testing.T) {
+	hub := NewHub()
+	offers := newStatusOffers()
+	r := newRestRouter(hub, offers)
+	events, cancel := hub.Listen(testOrderID, 1)
+	defer cancel()
+
+	w := serve(t, r, "POST", "/offers/"+testPendingOfferID+"/accept?order_id="+testOrderID, "", testClientID, "client")
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d %s, want 200", w.Code, w.Body)
+	}
+	var resp OfferResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Offer.GetStatus() != StatusAccepted {
+		t.Fatalf("status %q, want %q", resp.Offer.GetStatus(), StatusAccepted)
+	}
+	select {
+	case ev := <-events:
+		if ev.Action != typeOfferUpdated || ev.Offer.GetId() != testPendingOfferID {
+			t.Fatalf("got %s of %s, want offerUpdated of %s", ev.Action, ev.Offer.GetId(), testPendingOfferID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no offerUpdated event")
+	}
+}
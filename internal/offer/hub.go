@@ -2,46 +2,397 @@
 package offer
 
 import (
-    "sync"
-    "github.com/gorilla/websocket"
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	commonpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/common/v1"
+)
+
+// Event — уведомление подписчикам заказа об изменении оффера. Seq — номер
+// события в журнале заказа этого экземпляра (см. Position).
+type Event struct {
+	Action string                `json:"action"`
+	Offer  *commonpbv1.OfferData `json:"offer"`
+	Seq    uint64                `json:"seq,omitempty"`
+}
+
+const (
+	// publishTimeout ограничивает отправку события в брокер.
+	publishTimeout = 2 * time.Second
+	// publishQueueSize — сколько событий может ждать отправки в брокер.
+	// Если брокер не успевает, новые события для других экземпляров
+	// отбрасываются, а не задерживают обработчики запросов.
+	publishQueueSize = 256
+	// maxBrokerBackoff — наибольшая пауза перед переподключением к брокеру.
+	maxBrokerBackoff = 30 * time.Second
 )
 
+// brokerMessage — событие хаба в брокере. Instance позволяет экземпляру
+// узнать свои сообщения и не разослать их второй раз.
+type brokerMessage struct {
+	Instance string          `json:"instance"`
+	OrderID  string          `json:"order_id"`
+	Event    json.RawMessage `json:"event"`
+}
+
 type Hub struct {
-    // map[orderID]set of connections
-    subs   map[string]map[*websocket.Conn]bool
-    mu     sync.RWMutex
+	// map[orderID]set of subscribers
+	subs map[string]map[*subscriber]bool
+	// обратный индекс: map[subscriber]set of orderIDs
+	orders map[*subscriber]map[string]bool
+	// map[orderID]set of in-process listeners (GraphQL-подписки)
+	listeners map[string]map[chan Event]bool
+	// журналы событий заказов, за которыми следят или недавно следили
+	logs map[string]*eventLog
+	mu   sync.RWMutex
+
+	// broker связывает хабы разных экземпляров шлюза; nil — только локально
+	broker   Broker
+	instance string
+	// outbox — очередь событий на отправку в брокер
+	outbox chan []byte
 }
 
+// NewHub создаёт хаб одного экземпляра: события не выходят за пределы процесса.
 func NewHub() *Hub {
-    return &Hub{
-        subs: make(map[string]map[*websocket.Conn]bool),
-    }
-}
-
-func (h *Hub) Subscribe(orderID string, conn *websocket.Conn) {
-    h.mu.Lock()
-    defer h.mu.Unlock()
-    if h.subs[orderID] == nil {
-        h.subs[orderID] = make(map[*websocket.Conn]bool)
-    }
-    h.subs[orderID][conn] = true
-}
-
-func (h *Hub) Unsubscribe(orderID string, conn *websocket.Conn) {
-    h.mu.Lock()
-    defer h.mu.Unlock()
-    if conns := h.subs[orderID]; conns != nil {
-        delete(conns, conn)
-        if len(conns) == 0 {
-            delete(h.subs, orderID)
-        }
-    }
-}
-
-func (h *Hub) Broadcast(orderID string, message interface{}) {
-    h.mu.RLock()
-    defer h.mu.RUnlock()
-    for conn := range h.subs[orderID] {
-        conn.WriteJSON(message)
-    }
-}
\ No newline at end of file
+	return &Hub{
+		subs:      make(map[string]map[*subscriber]bool),
+		orders:    make(map[*subscriber]map[string]bool),
+		listeners: make(map[string]map[chan Event]bool),
+		logs:      make(map[string]*eventLog),
+	}
+}
+
+// NewClusterHub создаёт хаб, который делится событиями с другими экземплярами
+// через broker. instanceID должен быть уникален для каждого экземпляра.
+// Обмен событиями с брокером запускается методом Run.
+func NewClusterHub(instanceID string, broker Broker) *Hub {
+	h := NewHub()
+	h.broker = broker
+	h.instance = instanceID
+	h.outbox = make(chan []byte, publishQueueSize)
+	return h
+}
+
+// Run отправляет события этого экземпляра в брокер и принимает события
+// других, пока не отменён ctx, и переподключается к брокеру при обрыве.
+// Для хаба без брокера сразу возвращается.
+func (h *Hub) Run(ctx context.Context) {
+	if h.broker == nil {
+		return
+	}
+	go h.publish(ctx)
+	backoff := time.Second
+	for {
+		start := time.Now()
+		err := h.broker.Subscribe(ctx, h.receive)
+		if ctx.Err() != nil {
+			return
+		}
+		// долго проработавшее соединение сбрасывает паузу
+		if time.Since(start) > maxBrokerBackoff {
+			backoff = time.Second
+		}
+		log.Printf("offer hub: broker subscription lost: %v, retrying in %s", err, backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBrokerBackoff)
+	}
+}
+
+// Subscribe подписывает на события заказа. ack получает текущую позицию
+// журнала и вызывается под блокировкой хаба, так что ответ на подписку
+// уходит раньше любых событий. С resume после ответа досылаются события,
+// пропущенные с этой позиции; если досылка невозможна, ack получает
+// resync=true и клиенту стоит перечитать офферы заказа целиком. Так же
+// бывает, когда пропущенное вместе с ответом не помещается в очередь
+// подписчика: иначе он отключился бы как медленный сразу после переподключения.
+func (h *Hub) Subscribe(orderID string, sub *subscriber, resume *Position, ack func(pos Position, resync bool)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	l := h.follow(orderID)
+	var missed []Event
+	resync := false
+	if resume != nil {
+		var ok bool
+		missed, ok = l.since(*resume)
+		// одно место в очереди занимает ответ на подписку
+		if ok && len(missed) > sub.free()-1 {
+			missed, ok = nil, false
+		}
+		resync = !ok
+	}
+	ack(l.position(), resync)
+	for _, ev := range missed {
+		if msg, err := eventMessage(sub.protocol, orderID, ev); err == nil {
+			sub.SendRaw(msg)
+		}
+	}
+
+	if h.subs[orderID] == nil {
+		h.subs[orderID] = make(map[*subscriber]bool)
+	}
+	h.subs[orderID][sub] = true
+	if h.orders[sub] == nil {
+		h.orders[sub] = make(map[string]bool)
+	}
+	h.orders[sub][orderID] = true
+}
+
+func (h *Hub) Unsubscribe(orderID string, sub *subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.unsubscribe(orderID, sub)
+}
+
+// UnsubscribeAll отписывает подписчика от всех заказов, например при отключении.
+func (h *Hub) UnsubscribeAll(sub *subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for orderID := range h.orders[sub] {
+		h.unsubscribe(orderID, sub)
+	}
+}
+
+// unsubscribe вызывается под h.mu.
+func (h *Hub) unsubscribe(orderID string, sub *subscriber) {
+	if subs := h.subs[orderID]; subs != nil {
+		delete(subs, sub)
+		if len(subs) == 0 {
+			delete(h.subs, orderID)
+		}
+	}
+	if ids := h.orders[sub]; ids != nil {
+		delete(ids, orderID)
+		if len(ids) == 0 {
+			delete(h.orders, sub)
+		}
+	}
+	h.unfollow(orderID)
+}
+
+// follow возвращает журнал заказа, создавая его при первой подписке.
+// Вызывается под h.mu.
+func (h *Hub) follow(orderID string) *eventLog {
+	l := h.logs[orderID]
+	if l == nil {
+		h.sweep(time.Now())
+		l = newEventLog()
+		h.logs[orderID] = l
+	}
+	l.idleSince = time.Time{}
+	return l
+}
+
+// unfollow отмечает, что за заказом больше никто не следит. Журнал ещё
+// eventLogTTL ждёт переподключения. Вызывается под h.mu.
+func (h *Hub) unfollow(orderID string) {
+	if len(h.subs[orderID]) > 0 || len(h.listeners[orderID]) > 0 {
+		return
+	}
+	if l := h.logs[orderID]; l != nil {
+		l.idleSince = time.Now()
+	}
+}
+
+// sweep удаляет журналы, простоявшие без подписчиков дольше eventLogTTL.
+// Вызывается под h.mu.
+func (h *Hub) sweep(now time.Time) {
+	for id, l := range h.logs {
+		if !l.idleSince.IsZero() && now.Sub(l.idleSince) > eventLogTTL {
+			delete(h.logs, id)
+		}
+	}
+}
+
+// Followed возвращает заказы, за которыми следят клиенты этого экземпляра
+// или следили в пределах eventLogTTL: изменения по ним нужны для досылки.
+func (h *Hub) Followed() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.sweep(time.Now())
+	ids := make([]string, 0, len(h.logs))
+	for id := range h.logs {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Sync сверяет офферы заказа, полученные из сервиса, с уже разосланными и
+// рассылает локальным подписчикам появившиеся (offerCreated) и изменившиеся
+// (offerUpdated). Первый вызов по заказу только запоминает текущее состояние.
+// В брокер такие события не уходят: каждый экземпляр сверяет свои заказы сам.
+// Удалённые офферы Sync не замечает: события удаления в протоколе нет, и
+// пропавший из ответа оффер просто остаётся в versions.
+func (h *Hub) Sync(orderID string, offers []*commonpbv1.OfferData) {
+	var events []Event
+	h.mu.Lock()
+	l := h.logs[orderID]
+	if l == nil {
+		h.mu.Unlock()
+		return
+	}
+	seeded := l.seeded
+	l.seeded = true
+	for _, o := range offers {
+		v, ok := l.versions[o.GetId()]
+		switch {
+		case !seeded:
+			l.versions[o.GetId()] = offerVersion(o)
+		case !ok:
+			events = append(events, Event{Action: "offerCreated", Offer: o})
+		case v != offerVersion(o):
+			events = append(events, Event{Action: "offerUpdated", Offer: o})
+		}
+	}
+	h.mu.Unlock()
+
+	for _, ev := range events {
+		h.deliver(orderID, ev)
+	}
+}
+
+// Listen подписывает канал на события заказа. Если получатель не успевает
+// читать и буфер заполнен, события для него отбрасываются. Вызов cancel
+// отписывает и закрывает канал.
+func (h *Hub) Listen(orderID string, buffer int) (<-chan Event, func()) {
+	ch, cancel, _, _ := h.ListenFrom(orderID, buffer, nil)
+	return ch, cancel
+}
+
+// ListenFrom — Listen с досылкой: если задан resume, канал сначала получает
+// события, пропущенные с этой позиции. Возвращает позицию журнала на момент
+// подписки и resync=true, если пропущенное дослать нельзя.
+func (h *Hub) ListenFrom(orderID string, buffer int, resume *Position) (<-chan Event, func(), Position, bool) {
+	h.mu.Lock()
+	l := h.follow(orderID)
+	var missed []Event
+	resync := false
+	if resume != nil {
+		var ok bool
+		missed, ok = l.since(*resume)
+		resync = !ok
+	}
+	// досылаемые события не должны вытеснять друг друга
+	ch := make(chan Event, buffer+len(missed))
+	for _, ev := range missed {
+		ch <- ev
+	}
+	pos := l.position()
+	if h.listeners[orderID] == nil {
+		h.listeners[orderID] = make(map[chan Event]bool)
+	}
+	h.listeners[orderID][ch] = true
+	h.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			if chans := h.listeners[orderID]; chans != nil {
+				delete(chans, ch)
+				if len(chans) == 0 {
+					delete(h.listeners, orderID)
+				}
+			}
+			h.unfollow(orderID)
+			close(ch)
+		})
+	}
+	return ch, cancel, pos, resync
+}
+
+// Broadcast рассылает событие подписчикам заказа на этом экземпляре и,
+// если задан брокер, на остальных. Запись в соединения идёт через очереди
+// подписчиков, поэтому медленный клиент не задерживает остальных. В брокер
+// событие уходит через очередь outbox, так что Broadcast не ждёт брокер;
+// если очередь заполнена, событие достаётся только этому экземпляру.
+func (h *Hub) Broadcast(orderID string, ev Event) {
+	h.deliver(orderID, ev)
+	if h.broker == nil {
+		return
+	}
+	// номер события у каждого экземпляра свой, в брокер оно уходит без него
+	ev.Seq = 0
+	msg, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+	out, err := json.Marshal(brokerMessage{Instance: h.instance, OrderID: orderID, Event: msg})
+	if err != nil {
+		return
+	}
+	select {
+	case h.outbox <- out:
+	default:
+		log.Printf("offer hub: broker queue is full, %s of order %s not sent to other instances", ev.Action, orderID)
+	}
+}
+
+// publish отправляет события из outbox в брокер по одному, сохраняя их
+// порядок, пока не отменён ctx.
+func (h *Hub) publish(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case out := <-h.outbox:
+			pctx, cancel := context.WithTimeout(ctx, publishTimeout)
+			if err := h.broker.Publish(pctx, out); err != nil && ctx.Err() == nil {
+				log.Printf("offer hub: publish to broker: %v", err)
+			}
+			cancel()
+		}
+	}
+}
+
+// receive обрабатывает сообщение из брокера. Свои сообщения уже доставлены
+// локально в Broadcast и отбрасываются.
+func (h *Hub) receive(raw []byte) {
+	var m brokerMessage
+	if err := json.Unmarshal(raw, &m); err != nil || m.Instance == h.instance {
+		return
+	}
+	var ev Event
+	if err := json.Unmarshal(m.Event, &ev); err != nil {
+		return
+	}
+	h.deliver(m.OrderID, ev)
+}
+
+// deliver записывает событие в журнал заказа и передаёт локальным
+// подписчикам. Вместе с событием журнал запоминает версию оффера, чтобы
+// Sync не разослал его повторно.
+func (h *Hub) deliver(orderID string, ev Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if l := h.logs[orderID]; l != nil {
+		ev = l.append(ev)
+	}
+	// событие кодируется один раз на протокол и только при наличии его подписчиков
+	msgs := make(map[string][]byte, 2)
+	for sub := range h.subs[orderID] {
+		msg, ok := msgs[sub.protocol]
+		if !ok {
+			var err error
+			if msg, err = eventMessage(sub.protocol, orderID, ev); err != nil {
+				continue
+			}
+			msgs[sub.protocol] = msg
+		}
+		sub.SendRaw(msg)
+	}
+	for ch := range h.listeners[orderID] {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
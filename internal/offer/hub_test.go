@@ -0,0 +1,128 @@
+// internal/offer/hub_test.go
+package offer
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	commonpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/common/v1"
+)
+
+// hubIdle проверяет, что в хабе не осталось подписок, а журналы
+// заказов ждут истечения eventLogTTL.
+func hubIdle(h *Hub) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.subs) != 0 || len(h.orders) != 0 || len(h.listeners) != 0 {
+		return fmt.Errorf("hub not empty: %d orders with subscribers, %d subscribers, %d orders with listeners",
+			len(h.subs), len(h.orders), len(h.listeners))
+	}
+	for id, l := range h.logs {
+		if l.idleSince.IsZero() {
+			return fmt.Errorf("log of order %s is still followed", id)
+		}
+	}
+	return nil
+}
+
+func assertIdle(t *testing.T, h *Hub) {
+	t.Helper()
+	if err := hubIdle(h); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUnsubscribeAllLeavesEveryOrder(t *testing.T) {
+	h := NewHub()
+	sub, other := newQueueSubscriber(""), newQueueSubscriber("")
+	for _, id := range []string{"o1", "o2", "o3"} {
+		h.Subscribe(id, sub, nil, func(Position, bool) {})
+	}
+	h.Subscribe("o2", other, nil, func(Position, bool) {})
+
+	h.UnsubscribeAll(sub)
+	h.Broadcast("o1", Event{Action: typeOfferCreated, Offer: &commonpbv1.OfferData{Id: "f1"}})
+	h.Broadcast("o2", Event{Action: typeOfferCreated, Offer: &commonpbv1.OfferData{Id: "f2"}})
+	if len(sub.send) != 0 {
+		t.Fatalf("unsubscribed subscriber got %d events", len(sub.send))
+	}
+	if len(other.send) != 1 {
+		t.Fatalf("remaining subscriber got %d events, want 1", len(other.send))
+	}
+
+	h.UnsubscribeAll(other)
+	// повторная отписка ничего не ломает
+	h.UnsubscribeAll(other)
+	assertIdle(t, h)
+}
+
+func TestHubConcurrentSubscribeUnsubscribe(t *testing.T) {
+	h := NewHub()
+	orders := []string{"o1", "o2", "o3", "o4"}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				// очередь никто не разбирает: заодно переполняется и
+				// закрывается под нагрузкой
+				sub := newQueueSubscriber([]string{"", ProtocolV1}[i%2])
+				for _, id := range orders[:1+i%len(orders)] {
+					h.Subscribe(id, sub, nil, func(Position, bool) {})
+				}
+				h.Broadcast(orders[g%len(orders)], Event{
+					Action: typeOfferCreated,
+					Offer:  &commonpbv1.OfferData{Id: fmt.Sprintf("f%d-%d", g, i)},
+				})
+				if i%3 == 0 {
+					h.Unsubscribe(orders[0], sub)
+				}
+				h.UnsubscribeAll(sub)
+			}
+		}()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				ch, cancel := h.Listen(orders[(g+i)%len(orders)], 4)
+				select {
+				case <-ch:
+				default:
+				}
+				cancel()
+			}
+		}()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				h.Followed()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assertIdle(t, h)
+}
+
+func TestSlowSubscriberDoesNotBlockOthers(t *testing.T) {
+	h := NewHub()
+	slow, fast := newQueueSubscriber(""), newQueueSubscriber("")
+	h.Subscribe("o1", slow, nil, func(Position, bool) {})
+	h.Subscribe("o1", fast, nil, func(Position, bool) {})
+
+	for i := 0; i <= sendBuffer; i++ {
+		h.Broadcast("o1", Event{Action: typeOfferCreated, Offer: &commonpbv1.OfferData{Id: fmt.Sprint(i)}})
+		// быстрый подписчик успевает забирать события
+		<-fast.send
+	}
+	if !slow.slow.Load() || !isClosed(slow) {
+		t.Fatal("overflowing subscriber is not closed as slow")
+	}
+	if fast.slow.Load() || isClosed(fast) {
+		t.Fatal("reading subscriber was disconnected")
+	}
+}
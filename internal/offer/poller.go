@@ -0,0 +1,126 @@
+// internal/offer/poller.go
+package offer
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync/atomic"
+	"time"
+
+	offerpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/offer/v1"
+	"github.com/Ostap00034/course-work-backend-auth-service/util/jwt"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// pollParallelism — сколько заказов опрашивается одновременно.
+	pollParallelism = 8
+	// maxPollBackoff — наибольший интервал опроса, пока OfferService недоступен.
+	maxPollBackoff = time.Minute
+)
+
+// Poller доносит до подписчиков изменения офферов, сделанные в обход шлюза.
+//
+// Это обход ограничения спецификации: у OfferService нет потокового RPC и
+// номеров событий, с которых можно продолжить чтение. Поэтому Poller
+// периодически запрашивает офферы только тех заказов, за которыми сейчас
+// следят клиенты, и передаёт их в Hub.Sync. Последнее увиденное состояние
+// хранит хаб, так что после сбоя следующий удачный опрос разошлёт всё, что
+// изменилось за время недоступности. Изменения по заказу, за которым никто
+// не следил, Poller не видит: новый подписчик получает текущее состояние
+// заказа из REST, а не пропущенные события.
+//
+// Запросы идут от имени сервиса: token передаётся в metadata "authorization",
+// как токен пользователя из cookie. Токен проверяется при создании и должен
+// иметь срок действия; по его истечении опрос останавливается. Poller получает
+// только OfferLister, поэтому с этим токеном шлюз может лишь читать офферы.
+type Poller struct {
+	hub      *Hub
+	client   OfferLister
+	interval time.Duration
+	token    string
+	expires  time.Time
+}
+
+// OfferLister — единственный вызов OfferService, нужный Poller.
+type OfferLister interface {
+	GetMyOrderOffers(ctx context.Context, in *offerpbv1.GetMyOrderOffersRequest, opts ...grpc.CallOption) (*offerpbv1.GetMyOrderOffersResponse, error)
+}
+
+// NewPoller проверяет подпись и срок действия token и создаёт Poller.
+func NewPoller(hub *Hub, client OfferLister, interval time.Duration, token string) (*Poller, error) {
+	claims, err := jwt.ParseToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if claims.ExpiresAt == nil {
+		return nil, errors.New("token has no expiry")
+	}
+	return &Poller{hub: hub, client: client, interval: interval, token: token, expires: claims.ExpiresAt.Time}, nil
+}
+
+// Run опрашивает OfferService, пока не отменён ctx. Если не удалось сверить
+// ни один заказ, интервал удваивается до maxPollBackoff и возвращается к
+// исходному после опроса, в котором прошёл хотя бы один заказ.
+func (p *Poller) Run(ctx context.Context) {
+	wait := p.interval
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		if time.Now().After(p.expires) {
+			log.Printf("offer poller: token expired at %s, polling stopped", p.expires.Format(time.RFC3339))
+			return
+		}
+		if err := p.poll(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			wait = min(wait*2, maxPollBackoff)
+			log.Printf("offer poller: %v, next poll in %s", err, wait)
+			continue
+		}
+		wait = p.interval
+	}
+}
+
+var errPollFailed = errors.New("no order could be polled")
+
+// poll сверяет все отслеживаемые заказы. Ошибка по заказу записывается в
+// лог и не мешает остальным; заказы, которых больше нет, пропускаются.
+// Возвращает ошибку, только если не удалось сверить ни один заказ.
+func (p *Poller) poll(ctx context.Context) error {
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", p.token)
+	orders := p.hub.Followed()
+	var failed atomic.Int32
+	var g errgroup.Group
+	g.SetLimit(pollParallelism)
+	for _, orderID := range orders {
+		g.Go(func() error {
+			ctx, cancel := context.WithTimeout(ctx, p.interval)
+			defer cancel()
+			resp, err := p.client.GetMyOrderOffers(ctx, &offerpbv1.GetMyOrderOffersRequest{OrderId: orderID})
+			if err != nil {
+				if status.Code(err) != codes.NotFound {
+					failed.Add(1)
+					log.Printf("offer poller: order %s: %v", orderID, err)
+				}
+				return nil
+			}
+			p.hub.Sync(orderID, resp.Offers)
+			return nil
+		})
+	}
+	g.Wait()
+	if len(orders) > 0 && int(failed.Load()) == len(orders) {
+		return errPollFailed
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+// internal/offer/poller_test.go
+package offer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	commonpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/common/v1"
+	offerpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/offer/v1"
+	"github.com/Ostap00034/course-work-backend-auth-service/util/jwt"
+)
+
+// fakeLister отдаёт офферы заказов из offers; заказа нет — NotFound,
+// заказ из failing — Unavailable.
+type fakeLister struct {
+	mu      sync.Mutex
+	offers  map[string][]*commonpbv1.OfferData
+	failing map[string]bool
+	tokens  []string
+}
+
+func (f *fakeLister) GetMyOrderOffers(ctx context.Context, req *offerpbv1.GetMyOrderOffersRequest, _ ...grpc.CallOption) (*offerpbv1.GetMyOrderOffersResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	md, _ := metadata.FromOutgoingContext(ctx)
+	f.tokens = append(f.tokens, md.Get("authorization")...)
+	if f.failing[req.OrderId] {
+		return nil, status.Error(codes.Unavailable, "unavailable")
+	}
+	offers, ok := f.offers[req.OrderId]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "not found")
+	}
+	resp := &offerpbv1.GetMyOrderOffersResponse{}
+	for _, o := range offers {
+		resp.Offers = append(resp.Offers, proto.Clone(o).(*commonpbv1.OfferData))
+	}
+	return resp, nil
+}
+
+func (f *fakeLister) set(orderID string, offers ...*commonpbv1.OfferData) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.offers[orderID] = offers
+}
+
+func pollerToken(t *testing.T, claims jwt.Claims) string {
+	t.Helper()
+	token, err := jwt.GenerateToken(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestNewPollerToken(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{"valid", pollerToken(t, jwt.NewClaims("poller", "admin", time.Now().Add(time.Hour))), true},
+		{"expired", pollerToken(t, jwt.NewClaims("poller", "admin", time.Now().Add(-time.Hour))), false},
+		{"without expiry", pollerToken(t, jwt.Claims{UserID: "poller", Role: "admin"}), false},
+		{"not a token", "service-token", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPoller(NewHub(), &fakeLister{}, time.Second, tt.token)
+			if (err == nil) != tt.ok {
+				t.Fatalf("err = %v, want ok=%v", err, tt.ok)
+			}
+		})
+	}
+}
+
+// drain возвращает действия событий, уже лежащих в канале.
+func drain(ch <-chan Event) []string {
+	var got []string
+	for {
+		select {
+		case ev := <-ch:
+			got = append(got, ev.Action+":"+ev.Offer.GetId())
+		default:
+			return got
+		}
+	}
+}
+
+func TestPollerSyncDiff(t *testing.T) {
+	hub := NewHub()
+	lister := &fakeLister{offers: map[string][]*commonpbv1.OfferData{}, failing: map[string]bool{}}
+	token := pollerToken(t, jwt.NewClaims("poller", "admin", time.Now().Add(time.Hour)))
+	p, err := NewPoller(hub, lister, time.Second, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	first := &commonpbv1.OfferData{Id: "f1", Status: "pending", Price: 100}
+	lister.set("o1", first)
+	events, cancel := hub.Listen("o1", 16)
+	defer cancel()
+
+	steps := []struct {
+		name   string
+		offers []*commonpbv1.OfferData
+		want   []string
+	}{
+		{"first poll only remembers the state", []*commonpbv1.OfferData{first}, nil},
+		{"nothing changed", []*commonpbv1.OfferData{first}, nil},
+		{"new offer", []*commonpbv1.OfferData{first, {Id: "f2", Status: "pending", Price: 90}}, []string{"offerCreated:f2"}},
+		{"same offers again", []*commonpbv1.OfferData{first, {Id: "f2", Status: "pending", Price: 90}}, nil},
+		{"price changed", []*commonpbv1.OfferData{first, {Id: "f2", Status: "pending", Price: 80}}, []string{"offerUpdated:f2"}},
+		{"status changed", []*commonpbv1.OfferData{{Id: "f1", Status: "accepted", Price: 100}, {Id: "f2", Status: "pending", Price: 80}}, []string{"offerUpdated:f1"}},
+		{"offer vanished", []*commonpbv1.OfferData{{Id: "f1", Status: "accepted", Price: 100}}, nil},
+	}
+	for _, step := range steps {
+		lister.set("o1", step.offers...)
+		if err := p.poll(ctx); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		got := drain(events)
+		if len(got) != len(step.want) || (len(got) > 0 && got[0] != step.want[0]) {
+			t.Fatalf("%s: got %v, want %v", step.name, got, step.want)
+		}
+	}
+	// изменение, уже разосланное шлюзом, опрос не повторяет
+	changed := &commonpbv1.OfferData{Id: "f1", Status: "accepted", Price: 70}
+	hub.Broadcast("o1", Event{Action: "offerUpdated", Offer: changed})
+	if got := drain(events); len(got) != 1 {
+		t.Fatalf("broadcast: got %v", got)
+	}
+	lister.set("o1", changed)
+	if err := p.poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := drain(events); len(got) != 0 {
+		t.Fatalf("poll repeated a broadcast event: %v", got)
+	}
+	for _, tok := range lister.tokens {
+		if tok != token {
+			t.Fatalf("poll sent authorization %q", tok)
+		}
+	}
+}
+
+func TestPollerFailures(t *testing.T) {
+	hub := NewHub()
+	lister := &fakeLister{offers: map[string][]*commonpbv1.OfferData{}, failing: map[string]bool{"o1": true}}
+	token := pollerToken(t, jwt.NewClaims("poller", "admin", time.Now().Add(time.Hour)))
+	p, err := NewPoller(hub, lister, time.Second, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := p.poll(ctx); err != nil {
+		t.Fatalf("poll without followed orders: %v", err)
+	}
+	_, cancel1 := hub.Listen("o1", 1)
+	defer cancel1()
+	if err := p.poll(ctx); !errors.Is(err, errPollFailed) {
+		t.Fatalf("every order failed: got %v", err)
+	}
+	// удалённый заказ не считается сбоем
+	_, cancel2 := hub.Listen("gone", 1)
+	defer cancel2()
+	if err := p.poll(ctx); err != nil {
+		t.Fatalf("one order failed, the other is gone: %v", err)
+	}
+}
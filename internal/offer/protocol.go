@@ -0,0 +1,188 @@
+// internal/offer/protocol.go
+package offer
+
+import (
+	"encoding/json"
+
+	commonpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/common/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ProtocolV1 — подпротокол Sec-WebSocket-Protocol с типизированными
+// сообщениями Envelope. Клиенты, не запросившие подпротокол, получают
+// прежний формат {action, data}.
+const (
+	ProtocolV1      = "offers.v1"
+	protocolVersion = 1
+)
+
+// Типы сообщений. Запросы клиента и ответы на них имеют один тип, ответ
+// несёт id запроса. События хаба приходят без id.
+const (
+	typeSubscribe    = "subscribe"
+	typeUnsubscribe  = "unsubscribe"
+	typeCreateOffer  = "createOffer"
+	typeUpdateOffer  = "updateOffer"
+	typeOfferCreated = "offerCreated"
+	typeOfferUpdated = "offerUpdated"
+	// typeError — ответ на сообщение, которое не удалось разобрать
+	typeError = "error"
+)
+
+// Envelope — сообщение протокола offers.v1 в обе стороны.
+type Envelope struct {
+	Type    string          `json:"type"`
+	ID      string          `json:"id,omitempty"`
+	Version int             `json:"version"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+	Error   *ProtocolError  `json:"error,omitempty"`
+}
+
+// ProtocolError — ошибка обработки запроса. Code предназначен для программ,
+// Message — для людей.
+type ProtocolError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+var (
+	errInvalidFormat      = &ProtocolError{Code: "bad_request", Message: "invalid format"}
+	errBadData            = &ProtocolError{Code: "bad_request", Message: "bad data"}
+	errUnsupportedVersion = &ProtocolError{Code: "unsupported_version", Message: "unsupported protocol version"}
+	errUnknownType        = &ProtocolError{Code: "unknown_type", Message: "unknown action"}
+	errOrderNotFound      = &ProtocolError{Code: "not_found", Message: "order not found"}
+	errOfferNotFound      = &ProtocolError{Code: "not_found", Message: "offer not found"}
+	errInvalidTransition  = &ProtocolError{Code: "conflict", Message: "offer status cannot change this way"}
+	errForbidden          = &ProtocolError{Code: "forbidden", Message: "forbidden"}
+	errInternal           = &ProtocolError{Code: "internal", Message: "internal error"}
+	errIdemInFlight       = &ProtocolError{Code: "conflict", Message: "request with this idempotency_key is in progress"}
+	errIdemMismatch       = &ProtocolError{Code: "idempotency_mismatch", Message: "idempotency_key reused with different data"}
+)
+
+// wsMsg описывает общую обёртку для входящих сообщений без подпротокола
+type wsMsg struct {
+	Action string          `json:"action"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// orderPayload — пэйлоад unsubscribe и ответа на него
+type orderPayload struct {
+	OrderId string `json:"order_id"`
+}
+
+// subscribePayload — пэйлоад subscribe. С last_seq и epoch из прошлого
+// ответа на подписку досылаются события, пропущенные после last_seq.
+type subscribePayload struct {
+	OrderId string  `json:"order_id"`
+	LastSeq *uint64 `json:"last_seq,omitempty"`
+	Epoch   string  `json:"epoch,omitempty"`
+}
+
+// subscribedPayload — ответ на subscribe: текущая позиция журнала заказа.
+// resync означает, что пропущенные события дослать нельзя.
+type subscribedPayload struct {
+	OrderId string `json:"order_id"`
+	Epoch   string `json:"epoch"`
+	Seq     uint64 `json:"seq"`
+	Resync  bool   `json:"resync,omitempty"`
+}
+
+// offerPayload — ответ на createOffer и updateOffer
+type offerPayload struct {
+	Offer *commonpbv1.OfferData `json:"offer"`
+}
+
+// eventPayload — пэйлоад offerCreated и offerUpdated в offers.v1
+type eventPayload struct {
+	OrderId string                `json:"order_id"`
+	Offer   *commonpbv1.OfferData `json:"offer"`
+	Seq     uint64                `json:"seq"`
+}
+
+// request — входящее сообщение независимо от протокола.
+type request struct {
+	Type    string
+	ID      string
+	Payload json.RawMessage
+}
+
+// decodeRequest разбирает входящее сообщение по правилам протокола.
+// Вместе с ошибкой возвращает то, что удалось разобрать, чтобы ответ
+// можно было связать с запросом.
+func decodeRequest(protocol string, raw []byte) (request, *ProtocolError) {
+	if protocol != ProtocolV1 {
+		var m wsMsg
+		if json.Unmarshal(raw, &m) != nil {
+			return request{}, errInvalidFormat
+		}
+		return request{Type: m.Action, Payload: m.Data}, nil
+	}
+	var env Envelope
+	if json.Unmarshal(raw, &env) != nil {
+		return request{Type: typeError}, errInvalidFormat
+	}
+	req := request{Type: env.Type, ID: env.ID, Payload: env.Payload}
+	// version можно не указывать: тогда подразумевается версия подпротокола
+	if env.Version != 0 && env.Version != protocolVersion {
+		return req, errUnsupportedVersion
+	}
+	return req, nil
+}
+
+// encodeReply кодирует ответ на запрос. Без подпротокола пэйлоад
+// разворачивается в корень сообщения рядом с action и error.
+func encodeReply(protocol string, req request, payload interface{}, perr *ProtocolError) []byte {
+	var body json.RawMessage
+	if payload != nil {
+		var err error
+		if body, err = json.Marshal(payload); err != nil {
+			body, perr = nil, errInternal
+		}
+	}
+	if protocol == ProtocolV1 {
+		b, _ := json.Marshal(Envelope{Type: req.Type, ID: req.ID, Version: protocolVersion, Payload: body, Error: perr})
+		return b
+	}
+	fields := map[string]json.RawMessage{}
+	if body != nil {
+		json.Unmarshal(body, &fields)
+	}
+	if req.Type != "" {
+		fields["action"], _ = json.Marshal(req.Type)
+	}
+	if perr != nil {
+		fields["error"], _ = json.Marshal(perr.Message)
+	}
+	b, _ := json.Marshal(fields)
+	return b
+}
+
+// eventMessage кодирует событие хаба для подписчика с данным протоколом.
+func eventMessage(protocol, orderID string, ev Event) ([]byte, error) {
+	if protocol != ProtocolV1 {
+		return json.Marshal(ev)
+	}
+	body, err := json.Marshal(eventPayload{OrderId: orderID, Offer: ev.Offer, Seq: ev.Seq})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(Envelope{Type: ev.Action, Version: protocolVersion, Payload: body})
+}
+
+// rpcError переводит ошибку gRPC в ошибку протокола с сообщением сервиса.
+func rpcError(err error) *ProtocolError {
+	st := status.Convert(err)
+	code := "internal"
+	switch st.Code() {
+	case codes.InvalidArgument:
+		code = "bad_request"
+	case codes.NotFound:
+		code = "not_found"
+	case codes.PermissionDenied, codes.Unauthenticated:
+		code = "forbidden"
+	case codes.AlreadyExists, codes.FailedPrecondition, codes.Aborted:
+		code = "conflict"
+	}
+	return &ProtocolError{Code: code, Message: st.Message()}
+}
@@ -1 +1,32 @@
-package offer
\ No newline at end of file
+// internal/offer/request.go
+package offer
+
+// createOfferRequest — тело POST /offers и пэйлоад createOffer в WebSocket.
+// Правила binding проверяются на обоих путях; price_precision регистрирует
+// пакет order.
+type createOfferRequest struct {
+	OrderId        string  `json:"order_id" binding:"required,uuid"`
+	MasterId       string  `json:"master_id" binding:"required,uuid"`
+	Price          float32 `json:"price" binding:"required,gte=0.01,lte=10000000,price_precision"`
+	IdempotencyKey string  `json:"idempotency_key,omitempty" binding:"omitempty,max=255"`
+}
+
+// updateOfferRequest — пэйлоад updateOffer в WebSocket. Заказ нужен, чтобы
+// найти оффер и проверить права, как в offerRequest.
+type updateOfferRequest struct {
+	OfferId string `json:"offer_id" binding:"required,uuid"`
+	OrderId string `json:"order_id" binding:"required,uuid"`
+	Status  string `json:"status" binding:"required,oneof=pending accepted rejected withdrawn"`
+}
+
+// patchOfferRequest — тело PATCH /offers/:id. OfferService умеет менять
+// только статус оффера.
+type patchOfferRequest struct {
+	Status string `json:"status" binding:"required,oneof=pending accepted rejected withdrawn"`
+}
+
+// offerRequest — параметры запроса к /offers/:id. OfferService не ищет
+// оффер по id, поэтому нужен заказ, среди офферов которого он ищется.
+type offerRequest struct {
+	OrderId string `form:"order_id" binding:"required,uuid"`
+}
@@ -1 +1,24 @@
-package offer
\ No newline at end of file
+// internal/offer/response.go
+package offer
+
+import commonpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/common/v1"
+
+type Response struct {
+	Success bool              `json:"success"`
+	Message string            `json:"message"`
+	Errors  map[string]string `json:"errors,omitempty"`
+}
+
+func errorResponse(msg string, errs map[string]string) Response {
+	return Response{Success: false, Message: msg, Errors: errs}
+}
+
+type OfferResponse struct {
+	Response
+	Offer *commonpbv1.OfferData `json:"offer,omitempty"`
+}
+
+type OffersResponse struct {
+	Response
+	Offers []*commonpbv1.OfferData `json:"offers"`
+}
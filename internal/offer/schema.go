@@ -0,0 +1,190 @@
+// internal/offer/schema.go
+package offer
+
+//go:generate go run ../../cmd/asyncapi -o ../../cmd/api/docs/asyncapi.json
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// message — сообщение протокола для документации: тип и Go-тип пэйлоада
+// (nil, если пэйлоада нет).
+type message struct {
+	Type    string
+	Payload reflect.Type
+	Summary string
+}
+
+var (
+	clientMessages = []message{
+		{typeSubscribe, reflect.TypeOf(subscribePayload{}), "Подписаться на офферы заказа: клиенту заказа, назначенному мастеру, любому мастеру, пока заказ открыт, или администратору"},
+		{typeUnsubscribe, reflect.TypeOf(orderPayload{}), "Отписаться от офферов заказа"},
+		{typeCreateOffer, reflect.TypeOf(createOfferRequest{}), "Создать оффер"},
+		{typeUpdateOffer, reflect.TypeOf(updateOfferRequest{}), "Изменить статус оффера"},
+	}
+	serverMessages = []message{
+		{typeSubscribe, reflect.TypeOf(subscribedPayload{}), "Ответ на subscribe"},
+		{typeUnsubscribe, reflect.TypeOf(orderPayload{}), "Ответ на unsubscribe"},
+		{typeCreateOffer, reflect.TypeOf(offerPayload{}), "Ответ на createOffer"},
+		{typeUpdateOffer, reflect.TypeOf(offerPayload{}), "Ответ на updateOffer"},
+		{typeOfferCreated, reflect.TypeOf(eventPayload{}), "По заказу создан оффер"},
+		{typeOfferUpdated, reflect.TypeOf(eventPayload{}), "Оффер заказа изменён"},
+		{typeError, nil, "Сообщение не удалось разобрать"},
+	}
+)
+
+// AsyncAPI описывает протокол offers.v1 документом AsyncAPI 2.6. Схемы
+// пэйлоадов строятся по Go-типам, поэтому документ не расходится с кодом.
+// channel — путь WebSocket-эндпоинта.
+func AsyncAPI(channel string) map[string]interface{} {
+	g := schemaGen{defs: map[string]interface{}{}}
+	g.ref(reflect.TypeOf(ProtocolError{}))
+
+	messages := map[string]interface{}{}
+	refs := func(dir string, list []message) []interface{} {
+		out := make([]interface{}, 0, len(list))
+		for _, m := range list {
+			name := dir + exportName(m.Type)
+			messages[name] = map[string]interface{}{
+				"name":    m.Type,
+				"summary": m.Summary,
+				"payload": g.envelope(m),
+			}
+			out = append(out, map[string]interface{}{"$ref": "#/components/messages/" + name})
+		}
+		return out
+	}
+
+	return map[string]interface{}{
+		"asyncapi": "2.6.0",
+		"info": map[string]interface{}{
+			"title":       "Offers WebSocket",
+			"version":     ProtocolV1,
+			"description": "Подписка на офферы заказов и действия с ними. Подпротокол запрашивается заголовком Sec-WebSocket-Protocol: " + ProtocolV1 + ".",
+		},
+		"defaultContentType": "application/json",
+		"channels": map[string]interface{}{
+			channel: map[string]interface{}{
+				"bindings": map[string]interface{}{
+					"ws": map[string]interface{}{"method": http.MethodGet},
+				},
+				// publish — что отправляет клиент, subscribe — что он получает
+				"publish":   map[string]interface{}{"message": map[string]interface{}{"oneOf": refs("client", clientMessages)}},
+				"subscribe": map[string]interface{}{"message": map[string]interface{}{"oneOf": refs("server", serverMessages)}},
+			},
+		},
+		"components": map[string]interface{}{
+			"messages": messages,
+			"schemas":  g.defs,
+		},
+	}
+}
+
+// AsyncAPIHandler отдаёт AsyncAPI-документ для WebSocket-эндпоинта, путь
+// которого получается отбрасыванием "/asyncapi.json" от пути запроса.
+func AsyncAPIHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, AsyncAPI(strings.TrimSuffix(c.Request.URL.Path, "/asyncapi.json")))
+	}
+}
+
+// schemaGen строит JSON Schema по Go-типам; структуры попадают в defs
+// и подставляются ссылками.
+type schemaGen struct {
+	defs map[string]interface{}
+}
+
+// envelope — схема Envelope с конкретным типом и пэйлоадом сообщения.
+func (g *schemaGen) envelope(m message) map[string]interface{} {
+	props := map[string]interface{}{
+		"type":    map[string]interface{}{"const": m.Type},
+		"id":      map[string]interface{}{"type": "string", "description": "Идентификатор запроса; повторяется в ответе"},
+		"version": map[string]interface{}{"const": protocolVersion},
+		"error":   g.ref(reflect.TypeOf(ProtocolError{})),
+	}
+	if m.Payload != nil {
+		props["payload"] = g.ref(m.Payload)
+	}
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": props,
+		"required":   []string{"type", "version"},
+	}
+}
+
+var rawMessageType = reflect.TypeOf(json.RawMessage{})
+
+func (g *schemaGen) ref(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == rawMessageType {
+		return map[string]interface{}{}
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		name := exportName(t.Name())
+		if _, ok := g.defs[name]; !ok {
+			// заглушка до заполнения на случай рекурсивных типов
+			g.defs[name] = nil
+			g.defs[name] = g.object(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": g.ref(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.ref(t.Elem())}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// object описывает структуру по её JSON-тегам, как её видит encoding/json.
+func (g *schemaGen) object(t reflect.Type) map[string]interface{} {
+	props := map[string]interface{}{}
+	required := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = g.ref(f.Type)
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": props,
+		"required":   required,
+	}
+}
+
+// exportName делает первую букву заглавной: orderPayload → OrderPayload.
+func exportName(s string) string {
+	r, n := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[n:]
+}
@@ -0,0 +1,132 @@
+// internal/offer/sse.go
+package offer
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/auth"
+	orderpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/order/v1"
+)
+
+const (
+	// sseHeartbeat — как часто в тихий поток пишется комментарий, чтобы
+	// прокси не закрыли соединение по простою.
+	sseHeartbeat = 15 * time.Second
+	// sseRetry — через сколько миллисекунд браузеру переподключаться.
+	sseRetry = 3000
+
+	// события потока помимо событий хаба
+	sseSubscribed = "subscribed"
+	sseResync     = "resync"
+)
+
+// StreamOffersHandler отдаёт события офферов заказа потоком Server-Sent Events —
+// для сетей, где WebSocket недоступен. Права те же, что у subscribe в WebSocket.
+// Идентификатор события — позиция в журнале заказа "epoch:seq"; по заголовку
+// Last-Event-ID (или параметру last_event_id) досылаются пропущенные события.
+// Первым приходит событие subscribed с текущей позицией; resync в нём
+// означает, что пропущенное дослать нельзя и офферы стоит перечитать.
+func StreamOffersHandler(hub *Hub, orderClient orderpbv1.OrderServiceClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := auth.CurrentUser(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, errorResponse("требуется авторизация", nil))
+			return
+		}
+		orderID := c.Param("id")
+		if !writeFollowError(c, authorizeFollow(c.Request.Context(), orderClient, orderID, claims.UserID, claims.Role)) {
+			return
+		}
+
+		lastID := c.GetHeader("Last-Event-ID")
+		if lastID == "" {
+			lastID = c.Query("last_event_id")
+		}
+		var resume *Position
+		if lastID != "" {
+			// неразборчивый id равен неизвестной позиции: клиент получит resync
+			pos := parseEventID(lastID)
+			resume = &pos
+		}
+		events, cancel, pos, resync := hub.ListenFrom(orderID, sendBuffer, resume)
+		defer cancel()
+
+		h := c.Writer.Header()
+		h.Set("Content-Type", "text/event-stream")
+		h.Set("Cache-Control", "no-cache")
+		h.Set("Connection", "keep-alive")
+		// nginx иначе копит поток в буфере
+		h.Set("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+
+		sse.Encode(c.Writer, sse.Event{
+			Id:    eventID(pos),
+			Event: sseSubscribed,
+			Retry: sseRetry,
+			Data:  subscribedPayload{OrderId: orderID, Epoch: pos.Epoch, Seq: pos.Seq, Resync: resync},
+		})
+		c.Writer.Flush()
+
+		heartbeat := time.NewTicker(sseHeartbeat)
+		defer heartbeat.Stop()
+		last := pos.Seq
+		if resume != nil && !resync {
+			last = resume.Seq
+		}
+		for {
+			select {
+			case <-c.Request.Context().Done():
+				return
+			case ev, ok := <-events:
+				if !ok {
+					return
+				}
+				// события отбрасываются, если клиент не успевает читать
+				if ev.Seq > last+1 {
+					sse.Encode(c.Writer, sse.Event{
+						Event: sseResync,
+						Data:  orderPayload{OrderId: orderID},
+					})
+				}
+				last = ev.Seq
+				err := sse.Encode(c.Writer, sse.Event{
+					Id:    eventID(Position{Epoch: pos.Epoch, Seq: ev.Seq}),
+					Event: ev.Action,
+					Data:  eventPayload{OrderId: orderID, Offer: ev.Offer, Seq: ev.Seq},
+				})
+				if err != nil {
+					return
+				}
+				c.Writer.Flush()
+			case <-heartbeat.C:
+				if _, err := c.Writer.WriteString(": ping\n\n"); err != nil {
+					return
+				}
+				c.Writer.Flush()
+			}
+		}
+	}
+}
+
+func eventID(pos Position) string {
+	return pos.Epoch + ":" + strconv.FormatUint(pos.Seq, 10)
+}
+
+// parseEventID разбирает id события; для неразборчивого возвращает нулевую позицию.
+func parseEventID(id string) Position {
+	epoch, seq, ok := strings.Cut(id, ":")
+	if !ok {
+		return Position{}
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return Position{}
+	}
+	return Position{Epoch: epoch, Seq: n}
+}
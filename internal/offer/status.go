@@ -0,0 +1,61 @@
+// internal/offer/status.go
+package offer
+
+import (
+	commonpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/common/v1"
+)
+
+// Статусы оффера.
+const (
+	StatusPending   = "pending"
+	StatusAccepted  = "accepted"
+	StatusRejected  = "rejected"
+	StatusWithdrawn = "withdrawn"
+)
+
+// Роли участников относительно конкретного оффера.
+const (
+	actorClient = "client" // владелец заказа
+	actorMaster = "master" // мастер, предложивший оффер
+	actorAdmin  = "admin"
+)
+
+// transitions описывает допустимые переходы статусов оффера и кто может
+// их выполнить: принимает и отклоняет оффер клиент заказа, отзывает —
+// его мастер.
+var transitions = map[string]map[string][]string{
+	StatusPending: {
+		StatusAccepted:  {actorClient, actorAdmin},
+		StatusRejected:  {actorClient, actorAdmin},
+		StatusWithdrawn: {actorMaster, actorAdmin},
+	},
+}
+
+// canTransition сообщает, существует ли переход from → to.
+func canTransition(from, to string) bool {
+	_, ok := transitions[from][to]
+	return ok
+}
+
+// canPerform сообщает, может ли actor выполнить переход from → to.
+func canPerform(from, to, actor string) bool {
+	for _, a := range transitions[from][to] {
+		if a == actor {
+			return true
+		}
+	}
+	return false
+}
+
+// offerActor определяет роль пользователя по отношению к офферу заказа o.
+func offerActor(o *commonpbv1.OrderData, offer *commonpbv1.OfferData, userID, role string) string {
+	switch {
+	case role == actorAdmin:
+		return actorAdmin
+	case o.GetClient().GetId() == userID:
+		return actorClient
+	case offer.GetMaster().GetId() == userID:
+		return actorMaster
+	}
+	return ""
+}
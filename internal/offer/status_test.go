@@ -0,0 +1,63 @@
+// internal/offer/status_test.go
+package offer
+
+import (
+	"context"
+	"testing"
+
+	commonpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/common/v1"
+)
+
+const (
+	testPendingOfferID  = "00000000-0000-0000-0000-0000000000f1"
+	testAcceptedOfferID = "00000000-0000-0000-0000-0000000000f2"
+	testOtherUserID     = "00000000-0000-0000-0000-00000000b001"
+)
+
+// newStatusOffers возвращает OfferService с ожидающим и принятым офферами
+// мастера testMasterID по заказу testOrderID.
+func newStatusOffers() *fakeOffers {
+	offers := newFakeOffers()
+	for id, st := range map[string]string{testPendingOfferID: StatusPending, testAcceptedOfferID: StatusAccepted} {
+		offers.offers[id] = &commonpbv1.OfferData{
+			Id:     id,
+			Master: &commonpbv1.UserData{Id: testMasterID},
+			Order:  &commonpbv1.OrderData{Id: testOrderID},
+			Price:  1500,
+			Status: st,
+		}
+	}
+	return offers
+}
+
+func TestAuthorizeStatusChange(t *testing.T) {
+	offers := newStatusOffers()
+	tests := []struct {
+		name             string
+		orderID, offerID string
+		userID, role, to string
+		want             *ProtocolError
+	}{
+		{"client accepts", testOrderID, testPendingOfferID, testClientID, "client", StatusAccepted, nil},
+		{"client rejects", testOrderID, testPendingOfferID, testClientID, "client", StatusRejected, nil},
+		{"client cannot withdraw", testOrderID, testPendingOfferID, testClientID, "client", StatusWithdrawn, errForbidden},
+		{"master withdraws", testOrderID, testPendingOfferID, testMasterID, "master", StatusWithdrawn, nil},
+		{"master cannot accept own offer", testOrderID, testPendingOfferID, testMasterID, "master", StatusAccepted, errForbidden},
+		{"admin accepts", testOrderID, testPendingOfferID, testOtherUserID, "admin", StatusAccepted, nil},
+		{"foreign client", testOrderID, testPendingOfferID, testOtherUserID, "client", StatusAccepted, errForbidden},
+		{"foreign master", testOrderID, testPendingOfferID, testOtherUserID, "master", StatusRejected, errForbidden},
+		{"foreign user learns nothing about status", testOrderID, testAcceptedOfferID, testOtherUserID, "client", StatusRejected, errForbidden},
+		{"accepted is final", testOrderID, testAcceptedOfferID, testClientID, "client", StatusRejected, errInvalidTransition},
+		{"back to pending", testOrderID, testPendingOfferID, testOtherUserID, "admin", StatusPending, errInvalidTransition},
+		{"unknown offer", testOrderID, testOtherUserID, testClientID, "client", StatusAccepted, errOfferNotFound},
+		{"offer of another order", testOtherUserID, testPendingOfferID, testClientID, "client", StatusAccepted, errOfferNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := authorizeStatusChange(context.Background(), offers, fakeOrders{}, tt.orderID, tt.offerID, tt.userID, tt.role, tt.to)
+			if got != tt.want {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+// internal/offer/subscriber.go
+package offer
+
+import (
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// sendBuffer — сколько исходящих сообщений может ждать отправки. Подписчик,
+// который не успевает их забирать, считается медленным и отключается.
+const sendBuffer = 64
+
+// subscriber — WebSocket-подписчик хаба. Gorilla запрещает писать в соединение
+// из нескольких горутин, поэтому все записи (ответы, рассылки, ping) идут
+// через очередь send и единственную горутину writePump.
+type subscriber struct {
+	conn *websocket.Conn
+	// protocol — согласованный подпротокол; пустой для прежнего формата
+	protocol  string
+	send      chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+	// slow — отключён ли подписчик из-за переполнения очереди
+	slow atomic.Bool
+}
+
+// newSubscriber оборачивает соединение и запускает горутину записи.
+func newSubscriber(conn *websocket.Conn) *subscriber {
+	s := &subscriber{
+		conn:     conn,
+		protocol: conn.Subprotocol(),
+		send:     make(chan []byte, sendBuffer),
+		done:     make(chan struct{}),
+	}
+	go s.writePump()
+	return s
+}
+
+// Send ставит сообщение в очередь. Возвращает false, если подписчик уже
+// закрыт или не успевает читать (тогда он отключается).
+func (s *subscriber) Send(v interface{}) bool {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return false
+	}
+	return s.SendRaw(b)
+}
+
+// SendRaw ставит в очередь готовое JSON-сообщение.
+func (s *subscriber) SendRaw(b []byte) bool {
+	select {
+	case <-s.done:
+		return false
+	default:
+	}
+	select {
+	case s.send <- b:
+		return true
+	default:
+		s.slow.Store(true)
+		s.Close()
+		return false
+	}
+}
+
+// free — сколько сообщений ещё поместится в очередь.
+func (s *subscriber) free() int {
+	return cap(s.send) - len(s.send)
+}
+
+// Close останавливает запись и закрывает соединение. Можно вызывать повторно.
+func (s *subscriber) Close() {
+	s.closeOnce.Do(func() { close(s.done) })
+}
+
+// writePump — единственная горутина, которая пишет в соединение.
+func (s *subscriber) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		s.conn.Close()
+	}()
+
+	for {
+		select {
+		case msg := <-s.send:
+			s.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := s.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				s.Close()
+				return
+			}
+		case <-ticker.C:
+			s.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := s.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				s.Close()
+				return
+			}
+		case <-s.done:
+			code, reason := websocket.CloseNormalClosure, ""
+			if s.slow.Load() {
+				code, reason = websocket.CloseTryAgainLater, "slow consumer"
+			}
+			s.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(code, reason), time.Now().Add(writeWait))
+			return
+		}
+	}
+}
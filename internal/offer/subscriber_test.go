@@ -0,0 +1,187 @@
+// internal/offer/subscriber_test.go
+package offer
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// wsPair поднимает WebSocket-соединение и возвращает его серверный и
+// клиентский концы.
+func wsPair(t *testing.T) (*websocket.Conn, *websocket.Conn) {
+	t.Helper()
+	accepted := make(chan *websocket.Conn, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		accepted <- conn
+	}))
+	t.Cleanup(srv.Close)
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	select {
+	case server := <-accepted:
+		t.Cleanup(func() { server.Close() })
+		return server, client
+	case <-time.After(5 * time.Second):
+		t.Fatal("upgrade timed out")
+		return nil, nil
+	}
+}
+
+// newQueueSubscriber — подписчик без соединения и writePump: очередь
+// никто не разбирает, так что её состояние видно напрямую.
+func newQueueSubscriber(protocol string) *subscriber {
+	return &subscriber{
+		protocol: protocol,
+		send:     make(chan []byte, sendBuffer),
+		done:     make(chan struct{}),
+	}
+}
+
+func isClosed(s *subscriber) bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestSubscriberQueueOverflowClosesSlowConsumer(t *testing.T) {
+	s := newQueueSubscriber("")
+	for i := 0; i < sendBuffer; i++ {
+		if !s.SendRaw([]byte("{}")) {
+			t.Fatalf("message %d rejected before the queue is full", i)
+		}
+	}
+	if s.free() != 0 {
+		t.Fatalf("free() = %d on a full queue", s.free())
+	}
+	if s.SendRaw([]byte("{}")) {
+		t.Fatal("message accepted into a full queue")
+	}
+	if !s.slow.Load() || !isClosed(s) {
+		t.Fatal("overflowing subscriber is not closed as slow")
+	}
+	if s.SendRaw([]byte("{}")) {
+		t.Fatal("message accepted after close")
+	}
+}
+
+func TestSubscriberConcurrentSend(t *testing.T) {
+	server, client := wsPair(t)
+	s := newSubscriber(server)
+	defer s.Close()
+
+	// вместе все сообщения помещаются в очередь, даже если writePump стоит
+	const senders, perSender = 8, sendBuffer / 8
+	var wg sync.WaitGroup
+	for g := 0; g < senders; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perSender; i++ {
+				if !s.Send(map[string]int{"g": g, "i": i}) {
+					t.Errorf("sender %d: message %d rejected", g, i)
+				}
+			}
+		}()
+	}
+
+	// у каждого отправителя сообщения приходят по порядку
+	next := make([]int, senders)
+	client.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for n := 0; n < senders*perSender; n++ {
+		var m map[string]int
+		if err := client.ReadJSON(&m); err != nil {
+			t.Fatalf("read message %d: %v", n, err)
+		}
+		if m["i"] != next[m["g"]] {
+			t.Fatalf("sender %d: got message %d, want %d", m["g"], m["i"], next[m["g"]])
+		}
+		next[m["g"]]++
+	}
+	wg.Wait()
+	if s.slow.Load() {
+		t.Fatal("subscriber marked slow")
+	}
+}
+
+func TestSubscriberSendRacesClose(t *testing.T) {
+	server, _ := wsPair(t)
+	s := newSubscriber(server)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				s.SendRaw([]byte(fmt.Sprintf(`{"i":%d}`, i)))
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.Close()
+		s.Close()
+	}()
+	wg.Wait()
+
+	if s.SendRaw([]byte("{}")) {
+		t.Fatal("message accepted after close")
+	}
+}
+
+func TestSlowConsumerGetsTryAgainLater(t *testing.T) {
+	server, client := wsPair(t)
+	// writePump запускается после переполнения, чтобы клиент гарантированно отстал
+	s := &subscriber{
+		conn:     server,
+		protocol: server.Subprotocol(),
+		send:     make(chan []byte, sendBuffer),
+		done:     make(chan struct{}),
+	}
+	for i := 0; i <= sendBuffer; i++ {
+		s.SendRaw([]byte("{}"))
+	}
+	if !s.slow.Load() {
+		t.Fatal("subscriber not marked slow")
+	}
+	go s.writePump()
+
+	client.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for n := 0; ; n++ {
+		_, _, err := client.ReadMessage()
+		if err == nil {
+			if n >= sendBuffer {
+				t.Fatal("received more messages than the queue holds")
+			}
+			continue
+		}
+		var ce *websocket.CloseError
+		if !errors.As(err, &ce) {
+			t.Fatalf("read: %v, want a close frame", err)
+		}
+		if ce.Code != websocket.CloseTryAgainLater || ce.Text != "slow consumer" {
+			t.Fatalf("close %d %q, want %d %q", ce.Code, ce.Text, websocket.CloseTryAgainLater, "slow consumer")
+		}
+		return
+	}
+}
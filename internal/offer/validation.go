@@ -0,0 +1,58 @@
+// internal/offer/validation.go
+package offer
+
+import (
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// validate проверяет пэйлоад WebSocket по тем же правилам binding, что и REST.
+func validate(v interface{}) error {
+	return binding.Validator.ValidateStruct(v)
+}
+
+// offerValidationErrors переводит ошибки валидатора в сообщения по полям.
+func offerValidationErrors(err error) map[string]string {
+	errs := make(map[string]string)
+	ve, ok := err.(validator.ValidationErrors)
+	if !ok {
+		errs["body"] = "некорректный запрос"
+		return errs
+	}
+	for _, fe := range ve {
+		switch fe.Field() {
+		case "OrderId":
+			if fe.Tag() == "required" {
+				errs["order_id"] = "id заказа обязателен"
+			} else {
+				errs["order_id"] = "неверный формат id заказа"
+			}
+		case "MasterId":
+			if fe.Tag() == "required" {
+				errs["master_id"] = "id мастера обязателен"
+			} else {
+				errs["master_id"] = "неверный формат id мастера"
+			}
+		case "Price":
+			switch fe.Tag() {
+			case "required":
+				errs["price"] = "цена обязательна"
+			case "price_precision":
+				errs["price"] = "цена может содержать не более двух знаков после запятой"
+			default:
+				errs["price"] = "цена должна быть от 0.01 до 10000000"
+			}
+		case "Status":
+			if fe.Tag() == "required" {
+				errs["status"] = "статус обязателен"
+			} else {
+				errs["status"] = "статус должен быть pending, accepted, rejected или withdrawn"
+			}
+		case "IdempotencyKey":
+			errs["idempotency_key"] = "максимальная длина ключа 255 символов"
+		default:
+			errs[fe.Field()] = "некорректное значение"
+		}
+	}
+	return errs
+}
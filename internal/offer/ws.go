@@ -5,38 +5,26 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/idempotency"
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/order"
 	authpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/auth/v1"
 	offerpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/offer/v1"
+	orderpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/order/v1"
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
-// wsMsg описывает общую обёртку для входящих сообщений
-type wsMsg struct {
-	Action string          `json:"action"`
-	Data   json.RawMessage `json:"data"`
-}
-
-// createOfferPayload — пэйлоад для создания оффера
-type createOfferPayload struct {
-	OrderId  string  `json:"order_id"`
-	MasterId string  `json:"master_id"`
-	Price    float32 `json:"price"`
-}
-
-// updateOfferPayload — пэйлоад для обновления оффера
-type updateOfferPayload struct {
-	OfferId string `json:"offer_id"`
-	Status  string `json:"status"`
-}
-
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
 	CheckOrigin:     func(r *http.Request) bool { return true },
+	// клиент, запросивший offers.v1, получает типизированный протокол
+	Subprotocols: []string{ProtocolV1},
 }
 
 const (
@@ -45,14 +33,19 @@ const (
 	pingPeriod = (pongWait * 9) / 10
 )
 
-// OfferWsHandler возвращает Gin-хендлер WebSocket.
-//   - hub        — менеджер подписок, у которого реализованы методы Subscribe, Unsubscribe и Broadcast.
+// OfferWsHandler возвращает Gin-хендлер WebSocket. С подпротоколом offers.v1
+// сообщения идут в формате Envelope (см. AsyncAPI), без него — в прежнем {action, data}.
+//   - hub        — менеджер подписок, у которого реализованы методы Subscribe, Unsubscribe, UnsubscribeAll и Broadcast.
 //   - offerClient — gRPC-клиент OfferService.
 //   - authClient  — gRPC-клиент AuthService для проверки токена.
+//   - orderClient — gRPC-клиент OrderService для проверки доступа к заказу.
+//   - idem        — хранилище ключей идемпотентности для createOffer.
 func OfferWsHandler(
 	hub *Hub,
 	offerClient offerpbv1.OfferServiceClient,
 	authClient authpbv1.AuthServiceClient,
+	orderClient orderpbv1.OrderServiceClient,
+	idem *idempotency.Store,
 ) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
@@ -69,110 +62,186 @@ func OfferWsHandler(
 				websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "no auth"))
 			return
 		}
-		if _, err := authClient.ValidateToken(c, &authpbv1.ValidateTokenRequest{Token: token}); err != nil {
+		authResp, err := authClient.ValidateToken(c, &authpbv1.ValidateTokenRequest{Token: token})
+		if err != nil {
 			conn.WriteMessage(websocket.CloseMessage,
 				websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "unauthorized"))
 			return
 		}
+		userID := authResp.UserId
+		role := authResp.User.GetRole()
+
+		// дальше в соединение пишет только горутина подписчика
+		sub := newSubscriber(conn)
+		defer sub.Close()
+		// при отключении клиента отписать от всех заказов
+		defer hub.UnsubscribeAll(sub)
 
-		// 2) Настройка ping/pong для keep-alive
+		// 2) Продление чтения по pong; ping шлёт горутина подписчика
 		conn.SetReadDeadline(time.Now().Add(pongWait))
 		conn.SetPongHandler(func(string) error {
 			conn.SetReadDeadline(time.Now().Add(pongWait))
 			return nil
 		})
-		ticker := time.NewTicker(pingPeriod)
-		defer ticker.Stop()
-		go func() {
-			for range ticker.C {
-				conn.SetWriteDeadline(time.Now().Add(writeWait))
-				if conn.WriteMessage(websocket.PingMessage, nil) != nil {
-					return
-				}
-			}
-		}()
 
 		// 3) Основной цикл обработки сообщений
+		protocol := conn.Subprotocol()
+		reply := func(req request, payload interface{}, perr *ProtocolError) {
+			sub.SendRaw(encodeReply(protocol, req, payload, perr))
+		}
 		for {
 			_, raw, err := conn.ReadMessage()
 			if err != nil {
 				break
 			}
-			var m wsMsg
-			if json.Unmarshal(raw, &m) != nil {
-				conn.WriteJSON(gin.H{"error": "invalid format"})
+			req, perr := decodeRequest(protocol, raw)
+			if perr != nil {
+				reply(req, nil, perr)
 				continue
 			}
 
-			switch m.Action {
+			switch req.Type {
 			// подписаться на обновления конкретного заказа
-			case "subscribe":
-				var sub struct {
-					OrderId string `json:"order_id"`
+			case typeSubscribe:
+				var p subscribePayload
+				if err := json.Unmarshal(req.Payload, &p); err != nil || p.OrderId == "" {
+					reply(req, nil, errBadData)
+					continue
 				}
-				if err := json.Unmarshal(m.Data, &sub); err == nil {
-					hub.Subscribe(sub.OrderId, conn)
+				if perr := authorizeFollow(c.Request.Context(), orderClient, p.OrderId, userID, role); perr != nil {
+					reply(req, orderPayload{OrderId: p.OrderId}, perr)
+					continue
+				}
+				// с last_seq после ответа досылаются пропущенные события
+				var resume *Position
+				if p.LastSeq != nil {
+					resume = &Position{Epoch: p.Epoch, Seq: *p.LastSeq}
+				}
+				hub.Subscribe(p.OrderId, sub, resume, func(pos Position, resync bool) {
+					reply(req, subscribedPayload{OrderId: p.OrderId, Epoch: pos.Epoch, Seq: pos.Seq, Resync: resync}, nil)
+				})
+
+			// отписаться от обновлений заказа
+			case typeUnsubscribe:
+				var p orderPayload
+				if err := json.Unmarshal(req.Payload, &p); err != nil || p.OrderId == "" {
+					reply(req, nil, errBadData)
+					continue
 				}
+				hub.Unsubscribe(p.OrderId, sub)
+				reply(req, p, nil)
 
 			// создать новый оффер
-			case "createOffer":
-				var p createOfferPayload
-				if err := json.Unmarshal(m.Data, &p); err != nil {
-					conn.WriteJSON(gin.H{"action": "createOffer", "error": "bad data"})
+			case typeCreateOffer:
+				var p createOfferRequest
+				if err := json.Unmarshal(req.Payload, &p); err != nil || validate(&p) != nil {
+					reply(req, nil, errBadData)
 					continue
 				}
-				grpcResp, err := offerClient.CreateOffer(
-					context.Background(),
-					&offerpbv1.CreateOfferRequest{
-						OrderId:  p.OrderId,
-						MasterId: p.MasterId,
-						Price:    p.Price,
-					},
-				)
-				if err != nil {
-					st := status.Convert(err)
-					conn.WriteJSON(gin.H{"action": "createOffer", "error": st.Message()})
+				if perr := authorizeCreate(userID, role, p); perr != nil {
+					reply(req, nil, perr)
 					continue
 				}
-				// ответ инициатору
-				conn.WriteJSON(gin.H{"action": "createOffer", "offer": grpcResp.Offer})
-				// уведомить всех подписчиков заказа
-				hub.Broadcast(p.OrderId, gin.H{"action": "offerCreated", "offer": grpcResp.Offer})
+
+				// повтор с тем же idempotency_key получает первый ответ без нового оффера
+				var idemKey string
+				if p.IdempotencyKey != "" {
+					idemKey = idempotency.ScopedKey(userID, "ws createOffer", p.IdempotencyKey)
+					fp := idempotency.Fingerprint(
+						[]byte(p.OrderId),
+						[]byte(p.MasterId),
+						[]byte(strconv.FormatFloat(float64(p.Price), 'f', -1, 32)),
+					)
+					rec, state := idem.Begin(idemKey, fp)
+					switch state {
+					case idempotency.Replay:
+						var stored storedReply
+						json.Unmarshal(rec.Body, &stored)
+						if stored.Error != nil {
+							reply(req, nil, stored.Error)
+						} else {
+							reply(req, stored.Payload, nil)
+						}
+						continue
+					case idempotency.InFlight:
+						reply(req, nil, errIdemInFlight)
+						continue
+					case idempotency.Mismatch:
+						reply(req, nil, errIdemMismatch)
+						continue
+					}
+				}
+
+				// оффер создаётся и рассылается подписчикам заказа так же, как через REST
+				created, err := createOffer(c.Request.Context(), offerClient, hub, p)
+				var result storedReply
+				if err != nil {
+					result.Error = rpcError(err)
+				} else {
+					result.Payload, _ = json.Marshal(offerPayload{Offer: created})
+				}
+				// для идемпотентности хранится весь ответ — пэйлоад или ошибка, —
+				// а при повторе он кодируется заново под id и протокол нового
+				// запроса. Внутренние ошибки, как и 5xx в REST, не сохраняются,
+				// чтобы запрос можно было повторить
+				if idemKey != "" {
+					if result.Error != nil && result.Error.Code == errInternal.Code {
+						idem.Abort(idemKey)
+					} else {
+						body, _ := json.Marshal(result)
+						idem.Complete(idemKey, idempotency.Record{Body: body})
+					}
+				}
+				if result.Error != nil {
+					reply(req, nil, result.Error)
+				} else {
+					reply(req, result.Payload, nil)
+				}
 
 			// обновить статус существующего оффера
-			case "updateOffer":
-				var p updateOfferPayload
-				if err := json.Unmarshal(m.Data, &p); err != nil {
-					conn.WriteJSON(gin.H{"action": "updateOffer", "error": "bad data"})
+			case typeUpdateOffer:
+				var p updateOfferRequest
+				if err := json.Unmarshal(req.Payload, &p); err != nil || validate(&p) != nil {
+					reply(req, nil, errBadData)
 					continue
 				}
-				grpcResp, err := offerClient.UpdateOffer(
-					context.Background(),
-					&offerpbv1.UpdateOfferRequest{
-						Id:     p.OfferId,
-						Status: p.Status,
-					},
-				)
+				if perr := authorizeStatusChange(c.Request.Context(), offerClient, orderClient, p.OrderId, p.OfferId, userID, role, p.Status); perr != nil {
+					reply(req, nil, perr)
+					continue
+				}
+				updated, err := updateOffer(c.Request.Context(), offerClient, hub, p.OrderId, p.OfferId, p.Status)
 				if err != nil {
-					st := status.Convert(err)
-					conn.WriteJSON(gin.H{"action": "updateOffer", "error": st.Message()})
+					reply(req, nil, rpcError(err))
 					continue
 				}
-				// ответ инициатору
-				conn.WriteJSON(gin.H{"action": "updateOffer", "offer": grpcResp.Offer})
-				// и рассылка всем подписчикам по заказу
-				hub.Broadcast(p.OfferId, gin.H{"action": "offerUpdated", "offer": grpcResp.Offer})
+				reply(req, offerPayload{Offer: updated}, nil)
 
 			default:
-				conn.WriteJSON(gin.H{"error": "unknown action"})
+				reply(req, nil, errUnknownType)
 			}
 		}
+	}
+}
 
-		// при отключении клиента отписать от всех заказов
-		hub.mu.Lock()
-		for orderID := range hub.subs {
-			hub.Unsubscribe(orderID, conn)
+// storedReply — ответ на createOffer, сохранённый для повторов с тем же
+// idempotency_key.
+type storedReply struct {
+	Payload json.RawMessage `json:"payload,omitempty"`
+	Error   *ProtocolError  `json:"error,omitempty"`
+}
+
+// authorizeFollow проверяет, что пользователь может подписаться на заказ.
+// Возвращает ошибку для клиента или nil.
+func authorizeFollow(ctx context.Context, orderClient orderpbv1.OrderServiceClient, orderID, userID, role string) *ProtocolError {
+	resp, err := orderClient.GetOrderById(ctx, &orderpbv1.GetOrderByIdRequest{Id: orderID})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return errOrderNotFound
 		}
-		hub.mu.Unlock()
+		return errInternal
+	}
+	if !order.CanFollow(resp.Order, userID, role) {
+		return errForbidden
 	}
+	return nil
 }
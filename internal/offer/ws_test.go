@@ -0,0 +1,221 @@
+// internal/offer/ws_test.go
+package offer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/idempotency"
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/order"
+	authpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/auth/v1"
+	commonpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/common/v1"
+	offerpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/offer/v1"
+	orderpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/order/v1"
+)
+
+const (
+	testClientID = "00000000-0000-0000-0000-00000000c001"
+	testMasterID = "00000000-0000-0000-0000-00000000a001"
+	testOrderID  = "00000000-0000-0000-0000-00000000e001"
+)
+
+// fakeAuth принимает токены вида "роль:id пользователя".
+type fakeAuth struct {
+	authpbv1.AuthServiceClient
+}
+
+func (fakeAuth) ValidateToken(_ context.Context, req *authpbv1.ValidateTokenRequest, _ ...grpc.CallOption) (*authpbv1.ValidateTokenResponse, error) {
+	role, userID, ok := strings.Cut(req.Token, ":")
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+	return &authpbv1.ValidateTokenResponse{UserId: userID, User: &commonpbv1.UserData{Id: userID, Role: role}}, nil
+}
+
+// fakeOrders отдаёт открытый заказ клиента testClientID с любым id.
+type fakeOrders struct {
+	orderpbv1.OrderServiceClient
+}
+
+func (fakeOrders) GetOrderById(_ context.Context, req *orderpbv1.GetOrderByIdRequest, _ ...grpc.CallOption) (*orderpbv1.GetOrderByIdResponse, error) {
+	return &orderpbv1.GetOrderByIdResponse{Order: &commonpbv1.OrderData{
+		Id:     req.Id,
+		Status: order.StatusOpen,
+		Client: &commonpbv1.UserData{Id: testClientID},
+	}}, nil
+}
+
+// newWsServer поднимает OfferWsHandler на /ws с поддельными сервисами.
+func newWsServer(t *testing.T, hub *Hub, offers offerpbv1.OfferServiceClient) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/ws", OfferWsHandler(hub, offers, fakeAuth{}, fakeOrders{}, idempotency.NewStore(time.Minute)))
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// dialWs подключается к /ws с подпротоколом offers.v1 от имени token.
+func dialWs(srv *httptest.Server, token string) (*websocket.Conn, error) {
+	dialer := websocket.Dialer{Subprotocols: []string{ProtocolV1}, HandshakeTimeout: 5 * time.Second}
+	header := http.Header{"Cookie": {"token=" + token}}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", header)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return conn, nil
+}
+
+// call отправляет запрос offers.v1 и возвращает ответ на него. События,
+// пришедшие раньше ответа, кладутся в events, если он не nil.
+func call(conn *websocket.Conn, typ, id string, payload interface{}, events *[]Envelope) (Envelope, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return Envelope{}, err
+	}
+	if err := conn.WriteJSON(Envelope{Type: typ, ID: id, Version: protocolVersion, Payload: body}); err != nil {
+		return Envelope{}, err
+	}
+	for {
+		var env Envelope
+		if err := conn.ReadJSON(&env); err != nil {
+			return Envelope{}, err
+		}
+		if env.ID == id {
+			if env.Error != nil {
+				return env, fmt.Errorf("%s: %s", env.Error.Code, env.Error.Message)
+			}
+			return env, nil
+		}
+		if events != nil {
+			*events = append(*events, env)
+		}
+	}
+}
+
+// waitIdle ждёт, пока обработчики отключившихся клиентов отпишут их от хаба.
+func waitIdle(t *testing.T, h *Hub) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		err := hubIdle(h)
+		if err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWsConcurrentConnectDisconnect(t *testing.T) {
+	hub := NewHub()
+	srv := newWsServer(t, hub, nil)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 32; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			conn, err := dialWs(srv, "client:"+testClientID)
+			if err != nil {
+				t.Errorf("dial: %v", err)
+				return
+			}
+			defer conn.Close()
+			for i := 0; i < 3; i++ {
+				orderID := fmt.Sprintf("order-%d", (g+i)%4)
+				if _, err := call(conn, typeSubscribe, fmt.Sprint(i), subscribePayload{OrderId: orderID}, nil); err != nil {
+					t.Errorf("subscribe %s: %v", orderID, err)
+					return
+				}
+			}
+			if g%2 == 0 {
+				if _, err := call(conn, typeUnsubscribe, "u", orderPayload{OrderId: fmt.Sprintf("order-%d", g%4)}, nil); err != nil {
+					t.Errorf("unsubscribe: %v", err)
+				}
+			}
+			// треть клиентов прощается, остальные просто рвут соединение
+			if g%3 == 0 {
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+			}
+		}()
+	}
+	wg.Wait()
+
+	waitIdle(t, hub)
+}
+
+// countingOffers считает вызовы CreateOffer; оффер с ценой 13 сервис
+// отклоняет, как уже существующий.
+type countingOffers struct {
+	*fakeOffers
+	creates int
+}
+
+func (f *countingOffers) CreateOffer(ctx context.Context, req *offerpbv1.CreateOfferRequest, opts ...grpc.CallOption) (*offerpbv1.CreateOfferResponse, error) {
+	f.mu.Lock()
+	f.creates++
+	f.mu.Unlock()
+	if req.Price == 13 {
+		return nil, status.Error(codes.AlreadyExists, "оффер уже есть")
+	}
+	return f.fakeOffers.CreateOffer(ctx, req, opts...)
+}
+
+func TestWsCreateOfferIdempotency(t *testing.T) {
+	offers := &countingOffers{fakeOffers: newFakeOffers()}
+	srv := newWsServer(t, NewHub(), offers)
+	conn, err := dialWs(srv, "master:"+testMasterID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	create := func(id, key string, price float32) (Envelope, error) {
+		return call(conn, typeCreateOffer, id, createOfferRequest{
+			OrderId: testOrderID, MasterId: testMasterID, Price: price, IdempotencyKey: key,
+		}, nil)
+	}
+	first, err := create("1", "k1", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// повтор получает тот же оффер в ответе на свой id
+	again, err := create("2", "k1", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ID != "2" || string(again.Payload) != string(first.Payload) {
+		t.Fatalf("replay %s %s, want id 2 and %s", again.ID, again.Payload, first.Payload)
+	}
+	if _, err := create("3", "k1", 200); err == nil || !strings.HasPrefix(err.Error(), "idempotency_mismatch") {
+		t.Fatalf("other price with the same key: %v", err)
+	}
+	// ошибка сервиса тоже часть ответа и повторяется без нового вызова
+	for _, id := range []string{"4", "5"} {
+		if _, err := create(id, "k2", 13); err == nil || !strings.HasPrefix(err.Error(), "conflict") {
+			t.Fatalf("request %s: %v", id, err)
+		}
+	}
+	if offers.creates != 2 {
+		t.Fatalf("CreateOffer called %d times, want 2", offers.creates)
+	}
+}
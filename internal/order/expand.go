@@ -0,0 +1,106 @@
+// internal/order/expand.go
+package order
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	categorypbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/category/v1"
+	commonpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/common/v1"
+	userv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/user/v1"
+)
+
+// Связанные ресурсы, которые можно встроить в заказ через ?expand=.
+const (
+	expandClient   = "client"
+	expandMaster   = "master"
+	expandCategory = "category"
+)
+
+// parseExpand разбирает список через запятую. Возвращает неизвестное
+// значение вторым результатом.
+func parseExpand(raw string) (map[string]bool, string) {
+	out := make(map[string]bool)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		switch part {
+		case "":
+		case expandClient, expandMaster, expandCategory:
+			out[part] = true
+		default:
+			return nil, part
+		}
+	}
+	return out, ""
+}
+
+// expandOrder параллельно загружает связанные ресурсы заказа. Ошибка одного
+// ресурса не мешает остальным: она попадает в errs под именем поля.
+func expandOrder(
+	ctx context.Context,
+	o *commonpbv1.OrderData,
+	fields map[string]bool,
+	userClient userv1.UserServiceClient,
+	categoryClient categorypbv1.CategoryServiceClient,
+) (*ExpandedOrder, map[string]string) {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		expanded = &ExpandedOrder{}
+		errs     = make(map[string]string)
+	)
+	fail := func(field string, err error, notFound string) {
+		msg := "не удалось загрузить данные"
+		if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
+			msg = notFound
+		}
+		mu.Lock()
+		errs[field] = msg
+		mu.Unlock()
+	}
+	loadUser := func(field, id string, dst **commonpbv1.UserData) {
+		defer wg.Done()
+		resp, err := userClient.GetUserById(ctx, &userv1.GetUserByIdRequest{UserId: id})
+		if err != nil {
+			fail(field, err, "пользователь не найден")
+			return
+		}
+		mu.Lock()
+		*dst = resp.User
+		mu.Unlock()
+	}
+
+	if fields[expandClient] && o.GetClient().GetId() != "" {
+		wg.Add(1)
+		go loadUser(expandClient, o.GetClient().GetId(), &expanded.Client)
+	}
+	// у заказа без мастера встраивать нечего — это не ошибка
+	if fields[expandMaster] && o.GetMaster().GetId() != "" {
+		wg.Add(1)
+		go loadUser(expandMaster, o.GetMaster().GetId(), &expanded.Master)
+	}
+	if fields[expandCategory] && o.GetCategoryId() != "" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := categoryClient.GetCategoryById(ctx, &categorypbv1.GetCategoryByIdRequest{Id: o.GetCategoryId()})
+			if err != nil {
+				fail(expandCategory, err, "категория не найдена")
+				return
+			}
+			mu.Lock()
+			expanded.Category = resp.Category
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) == 0 {
+		errs = nil
+	}
+	return expanded, errs
+}
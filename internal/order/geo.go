@@ -0,0 +1,42 @@
+// internal/order/geo.go
+package order
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+const earthRadiusKm = 6371.0
+
+// parseCoordinate разбирает координату из строки и проверяет диапазон.
+func parseCoordinate(raw string, limit float64) (float64, bool) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, false
+	}
+	if v < -limit || v > limit {
+		return 0, false
+	}
+	return v, true
+}
+
+// parseLatitude разбирает широту в диапазоне [-90, 90].
+func parseLatitude(raw string) (float64, bool) {
+	return parseCoordinate(raw, 90)
+}
+
+// parseLongitude разбирает долготу в диапазоне [-180, 180].
+func parseLongitude(raw string) (float64, bool) {
+	return parseCoordinate(raw, 180)
+}
+
+// distanceKm считает расстояние между двумя точками по формуле гаверсинуса.
+func distanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := func(d float64) float64 { return d * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
@@ -0,0 +1,174 @@
+// internal/order/geo_test.go
+package order
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+
+	commonpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/common/v1"
+	orderpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/order/v1"
+)
+
+func TestParseCoordinate(t *testing.T) {
+	tests := []struct {
+		raw      string
+		lat, lon bool
+	}{
+		{"55.7558", true, true},
+		{" -33.9 ", true, true},
+		{"90", true, true},
+		{"-90", true, true},
+		{"90.0001", false, true},
+		{"180", false, true},
+		{"-180.5", false, false},
+		{"1e2", false, true},
+		{"", false, false},
+		{"abc", false, false},
+		{"NaN", false, false},
+		{"Inf", false, false},
+	}
+	for _, tt := range tests {
+		if _, ok := parseLatitude(tt.raw); ok != tt.lat {
+			t.Errorf("parseLatitude(%q) ok = %v, want %v", tt.raw, ok, tt.lat)
+		}
+		if _, ok := parseLongitude(tt.raw); ok != tt.lon {
+			t.Errorf("parseLongitude(%q) ok = %v, want %v", tt.raw, ok, tt.lon)
+		}
+	}
+}
+
+func TestDistanceKm(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lat1, lon1, lat2, lon2 float64
+		want, tolerance        float64
+	}{
+		{"same point", 55.7558, 37.6173, 55.7558, 37.6173, 0, 1e-9},
+		{"one degree of latitude", 0, 0, 1, 0, 111.19, 0.01},
+		{"Moscow to Saint Petersburg", 55.7558, 37.6173, 59.9343, 30.3351, 633, 2},
+		{"across the antimeridian", 0, 179.5, 0, -179.5, 111.19, 0.01},
+		{"antipodes", 0, 0, 0, 180, math.Pi * earthRadiusKm, 1e-6},
+	}
+	for _, tt := range tests {
+		got := distanceKm(tt.lat1, tt.lon1, tt.lat2, tt.lon2)
+		if math.Abs(got-tt.want) > tt.tolerance {
+			t.Errorf("%s: %.3f km, want %.3f±%g", tt.name, got, tt.want, tt.tolerance)
+		}
+		if back := distanceKm(tt.lat2, tt.lon2, tt.lat1, tt.lon1); math.Abs(back-got) > 1e-9 {
+			t.Errorf("%s: distance is not symmetric: %v and %v", tt.name, got, back)
+		}
+	}
+}
+
+// listOrders отдаёт заказы для GET /orders/nearby и запоминает запрос.
+type listOrders struct {
+	orderpbv1.OrderServiceClient
+	orders []*commonpbv1.OrderData
+	req    *orderpbv1.GetOrdersRequest
+}
+
+func (f *listOrders) GetOrders(_ context.Context, req *orderpbv1.GetOrdersRequest, _ ...grpc.CallOption) (*orderpbv1.GetOrdersResponse, error) {
+	f.req = req
+	return &orderpbv1.GetOrdersResponse{Orders: f.orders}, nil
+}
+
+func TestNearbyOrders(t *testing.T) {
+	// точки к северу от (55, 37): 0.1° широты ≈ 11.12 км
+	at := func(id, lat, lon string) *commonpbv1.OrderData {
+		return &commonpbv1.OrderData{Id: id, Latitude: lat, Longitude: lon}
+	}
+	orders := &listOrders{orders: []*commonpbv1.OrderData{
+		at("far", "55.5", "37"),
+		at("near", "55.1", "37"),
+		at("here", "55", "37"),
+		at("middle", "55.2", "37"),
+		at("broken", "север", "37"),
+		at("outside", "91", "37"),
+	}}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterHandlers(r.Group("/orders"), orders, fakeUsers{}, nil, nil)
+
+	tests := []struct {
+		name, query string
+		code        int
+		ids         []string
+		distances   []float64
+		errors      map[string]string
+	}{
+		{"default radius is 10 km", "lat=55&lon=37", http.StatusOK,
+			[]string{"here"}, []float64{0}, nil},
+		{"radius", "lat=55&lon=37&radius_km=25", http.StatusOK,
+			[]string{"here", "near", "middle"}, []float64{0, 11.12, 22.24}, nil},
+		{"sorted by distance", "lat=55&lon=37&radius_km=100", http.StatusOK,
+			[]string{"here", "near", "middle", "far"}, []float64{0, 11.12, 22.24, 55.6}, nil},
+		{"from another point", "lat=55.5&lon=37&radius_km=40", http.StatusOK,
+			[]string{"far", "middle"}, []float64{0, 33.36}, nil},
+		{"missing coordinates", "", http.StatusBadRequest, nil, nil,
+			map[string]string{"lat": "широта обязательна", "lon": "долгота обязательна"}},
+		{"latitude out of range", "lat=91&lon=37", http.StatusBadRequest, nil, nil,
+			map[string]string{"lat": "широта должна быть числом от -90 до 90"}},
+		{"longitude is not a number", "lat=55&lon=восток", http.StatusBadRequest, nil, nil,
+			map[string]string{"lon": "долгота должна быть числом от -180 до 180"}},
+		{"radius too large", "lat=55&lon=37&radius_km=501", http.StatusBadRequest, nil, nil,
+			map[string]string{"radius_km": "радиус должен быть больше 0 и не больше 500 км"}},
+		{"negative radius", "lat=55&lon=37&radius_km=-1", http.StatusBadRequest, nil, nil,
+			map[string]string{"radius_km": "радиус должен быть больше 0 и не больше 500 км"}},
+		{"radius is not a number", "lat=55&lon=37&radius_km=far", http.StatusBadRequest, nil, nil,
+			map[string]string{"query": "некорректные параметры запроса"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders/nearby?"+tt.query, nil))
+			if w.Code != tt.code {
+				t.Fatalf("got %d %s, want %d", w.Code, w.Body, tt.code)
+			}
+			var resp struct {
+				NearbyOrdersResponse
+				Errors map[string]string `json:"errors"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if tt.code != http.StatusOK {
+				if !reflect.DeepEqual(resp.Errors, tt.errors) {
+					t.Fatalf("errors %v, want %v", resp.Errors, tt.errors)
+				}
+				return
+			}
+			ids, distances := []string{}, []float64{}
+			for _, o := range resp.Orders {
+				ids = append(ids, o.Order.GetId())
+				distances = append(distances, o.DistanceKm)
+			}
+			if !reflect.DeepEqual(ids, tt.ids) || !reflect.DeepEqual(distances, tt.distances) {
+				t.Fatalf("got %v %v, want %v %v", ids, distances, tt.ids, tt.distances)
+			}
+		})
+	}
+}
+
+func TestNearbyOrdersFilters(t *testing.T) {
+	orders := &listOrders{}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterHandlers(r.Group("/orders"), orders, fakeUsers{}, nil, nil)
+	const categoryID = "00000000-0000-0000-0000-0000000000aa"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders/nearby?lat=1&lon=2&status=open&category_id="+categoryID, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d %s", w.Code, w.Body)
+	}
+	if orders.req.GetStatus() != StatusOpen || !reflect.DeepEqual(orders.req.GetCategoriesIds(), []string{categoryID}) {
+		t.Fatalf("service got %v", orders.req)
+	}
+}
@@ -2,11 +2,23 @@
 package order
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
 	"net/http"
-	"strings"
+	"sort"
 
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/auth"
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/idempotency"
+	"github.com/Ostap00034/course-work-backend-api-gateway/util"
+	categorypbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/category/v1"
+	commonpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/common/v1"
 	orderpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/order/v1"
+	userv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/user/v1"
+	"github.com/Ostap00034/course-work-backend-auth-service/util/jwt"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"google.golang.org/grpc/codes"
@@ -19,25 +31,7 @@ func CreateOrderHandler(client orderpbv1.OrderServiceClient) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req createOrderRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
-			errs := make(map[string]string)
-			if ve, ok := err.(validator.ValidationErrors); ok {
-				for _, fe := range ve {
-					errs[strings.ToLower(fe.Field())] = "неверное или отсутствует поле"
-				}
-			} else {
-				errs["body"] = "некорректный запрос"
-			}
-			c.JSON(http.StatusBadRequest, errorResponse("ошибка валидации", errs))
-			return
-		}
-
-		if _, err := uuid.Parse(req.ClientId); err != nil {
-			c.JSON(http.StatusBadRequest, errorResponse("неверный формат client_id", nil))
-			return
-		}
-
-		if _, err := uuid.Parse(req.CategoryId); err != nil {
-			c.JSON(http.StatusBadRequest, errorResponse("неверный формат categories_ids", nil))
+			c.JSON(http.StatusBadRequest, errorResponse("ошибка валидации", orderValidationErrors(err)))
 			return
 		}
 
@@ -130,26 +124,62 @@ func GetOrdersHandler(client orderpbv1.OrderServiceClient) gin.HandlerFunc {
 	}
 }
 
-func GetOrderHandler(client orderpbv1.OrderServiceClient) gin.HandlerFunc {
+// GetOrderHandler возвращает заказ. С ?expand=client,master,category
+// встраивает связанные ресурсы, загружая их параллельно.
+func GetOrderHandler(client orderpbv1.OrderServiceClient, userClient userv1.UserServiceClient, categoryClient categorypbv1.CategoryServiceClient) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id := c.Param("id")
 		if _, err := uuid.Parse(id); err != nil {
 			c.JSON(http.StatusBadRequest, errorResponse("неверный формат id", nil))
 			return
 		}
+		fields, unknown := parseExpand(c.Query("expand"))
+		if unknown != "" {
+			c.JSON(http.StatusBadRequest, errorResponse("ошибка валидации", map[string]string{
+				"expand": fmt.Sprintf("неизвестное поле %q, допустимы client, master, category", unknown),
+			}))
+			return
+		}
 		resp, err := client.GetOrderById(c, &orderpbv1.GetOrderByIdRequest{Id: id})
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, errorResponse("внутренняя ошибка сервера", nil))
 			return
 		}
+
+		if len(fields) == 0 {
+			etag := util.ETag(resp.Order)
+			util.SetETag(c, etag)
+			if util.NotModified(c, etag) {
+				c.Status(http.StatusNotModified)
+				return
+			}
+			c.JSON(http.StatusOK, OrderResponse{
+				Response: Response{Success: true, Message: "успешно"},
+				Order:    resp.Order,
+			})
+			return
+		}
+
+		expanded, errs := expandOrder(c, resp.Order, fields, userClient, categoryClient)
+		// неполный ответ не помечаем ETag, чтобы клиент не закэшировал его
+		if errs == nil {
+			etag := util.ETag(resp.Order, util.ETag(expanded.Client), util.ETag(expanded.Master), util.ETag(expanded.Category))
+			util.SetETag(c, etag)
+			if util.NotModified(c, etag) {
+				c.Status(http.StatusNotModified)
+				return
+			}
+		}
 		c.JSON(http.StatusOK, OrderResponse{
-			Response: Response{Success: true, Message: "успешно"},
-			Order:    resp.Order,
+			Response:     Response{Success: true, Message: "успешно"},
+			Order:        resp.Order,
+			Expanded:     expanded,
+			ExpandErrors: errs,
 		})
 	}
 }
 
-func UpdateOrderHandler(client orderpbv1.OrderServiceClient) gin.HandlerFunc {
+func UpdateOrderHandler(client orderpbv1.OrderServiceClient, userClient userv1.UserServiceClient) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id := c.Param("id")
 		if _, err := uuid.Parse(id); err != nil {
@@ -159,38 +189,30 @@ func UpdateOrderHandler(client orderpbv1.OrderServiceClient) gin.HandlerFunc {
 
 		var req updateOrderRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
-			errs := make(map[string]string)
-			if ve, ok := err.(validator.ValidationErrors); ok {
-				for _, fe := range ve {
-					errs[strings.ToLower(fe.Field())] = "неверное значение"
-				}
-			} else {
-				errs["body"] = "некорректный запрос"
-			}
-			c.JSON(http.StatusBadRequest, errorResponse("ошибка валидации", errs))
+			c.JSON(http.StatusBadRequest, errorResponse("ошибка валидации", orderValidationErrors(err)))
 			return
 		}
 
-		if req.ClientId != "" {
-			if _, err := uuid.Parse(req.ClientId); err != nil {
-				c.JSON(http.StatusBadRequest, errorResponse("неверный формат client_id", nil))
-				return
-			}
+		current, ok := orderForUpdate(c, client, id, req.Status != "")
+		if !ok {
+			return
 		}
-		if req.MasterId != "" {
-			if _, err := uuid.Parse(req.MasterId); err != nil {
-				c.JSON(http.StatusBadRequest, errorResponse("неверный формат master_id", nil))
-				return
-			}
+		if req.Status != "" && !authorizeStatusChange(c, current, req.Status, req.MasterId != "") {
+			return
 		}
-		if req.CategoryId != "" {
-			if _, err := uuid.Parse(req.CategoryId); err != nil {
-				c.JSON(http.StatusBadRequest, errorResponse("неверный формат category_id", nil))
-				return
-			}
+		if req.MasterId != "" && !checkAssignee(c, userClient, req.MasterId) {
+			return
+		}
+
+		mask := append([]string{}, orderReplaceFields...)
+		if req.Status != "" {
+			mask = append(mask, "status")
+		}
+		if req.MasterId != "" {
+			mask = append(mask, "master_id")
 		}
 
-		resp, err := client.UpdateOrder(c, &orderpbv1.UpdateOrderRequest{
+		resp, err := client.UpdateOrder(util.WithFieldMask(c.Request.Context(), mask...), &orderpbv1.UpdateOrderRequest{
 			Id:          id,
 			Title:       req.Title,
 			Description: req.Description,
@@ -208,6 +230,7 @@ func UpdateOrderHandler(client orderpbv1.OrderServiceClient) gin.HandlerFunc {
 			return
 		}
 
+		util.SetETag(c, util.ETag(resp.Order))
 		c.JSON(http.StatusOK, OrderResponse{
 			Response: Response{Success: true, Message: "успешно"},
 			Order:    resp.Order,
@@ -215,6 +238,107 @@ func UpdateOrderHandler(client orderpbv1.OrderServiceClient) gin.HandlerFunc {
 	}
 }
 
+func PatchOrderHandler(client orderpbv1.OrderServiceClient, userClient userv1.UserServiceClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		if _, err := uuid.Parse(id); err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse("неверный формат id", nil))
+			return
+		}
+
+		var req patchOrderRequest
+		present, err := util.ReadMergePatch(c, &req)
+		if err != nil {
+			if errors.Is(err, util.ErrUnsupportedMediaType) {
+				c.JSON(http.StatusUnsupportedMediaType, errorResponse("ожидается application/merge-patch+json", nil))
+			} else {
+				c.JSON(http.StatusBadRequest, errorResponse("ошибка валидации", map[string]string{"body": "некорректный запрос"}))
+			}
+			return
+		}
+		if err := binding.Validator.ValidateStruct(&req); err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse("ошибка валидации", orderValidationErrors(err)))
+			return
+		}
+
+		upd := &orderpbv1.UpdateOrderRequest{Id: id}
+		errs := make(map[string]string)
+		for k := range present {
+			var isNull bool
+			switch k {
+			case "title":
+				isNull, upd.Title = req.Title == nil, deref(req.Title)
+			case "description":
+				isNull, upd.Description = req.Description == nil, deref(req.Description)
+			case "price":
+				isNull, upd.Price = req.Price == nil, deref(req.Price)
+			case "address":
+				isNull, upd.Address = req.Address == nil, deref(req.Address)
+			case "longitude":
+				isNull, upd.Longitude = req.Longitude == nil, deref(req.Longitude)
+			case "latitude":
+				isNull, upd.Latitude = req.Latitude == nil, deref(req.Latitude)
+			case "status":
+				isNull, upd.Status = req.Status == nil, deref(req.Status)
+			case "category_id":
+				isNull, upd.CategoryId = req.CategoryId == nil, deref(req.CategoryId)
+			case "client_id":
+				isNull, upd.ClientId = req.ClientId == nil, deref(req.ClientId)
+			case "master_id":
+				isNull, upd.MasterId = req.MasterId == nil, deref(req.MasterId)
+			default:
+				errs[k] = "неизвестное поле"
+				continue
+			}
+			if isNull && !orderNullableFields[k] {
+				errs[k] = "поле нельзя очистить"
+			}
+		}
+		if len(errs) > 0 {
+			c.JSON(http.StatusBadRequest, errorResponse("ошибка валидации", errs))
+			return
+		}
+
+		mask := util.MaskFromPresent(present, orderPatchFields...)
+		if len(mask) == 0 {
+			c.JSON(http.StatusBadRequest, errorResponse("нет полей для изменения", nil))
+			return
+		}
+
+		current, ok := orderForUpdate(c, client, id, req.Status != nil)
+		if !ok {
+			return
+		}
+		if req.Status != nil && !authorizeStatusChange(c, current, *req.Status, req.MasterId != nil) {
+			return
+		}
+		if req.MasterId != nil && !checkAssignee(c, userClient, *req.MasterId) {
+			return
+		}
+
+		resp, err := client.UpdateOrder(util.WithFieldMask(c.Request.Context(), mask...), upd)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, errorResponse("внутренняя ошибка сервера", nil))
+			return
+		}
+
+		util.SetETag(c, util.ETag(resp.Order))
+		c.JSON(http.StatusOK, OrderResponse{
+			Response: Response{Success: true, Message: "успешно"},
+			Order:    resp.Order,
+		})
+	}
+}
+
+// deref возвращает значение указателя или нулевое значение для nil.
+func deref[T any](p *T) T {
+	var zero T
+	if p == nil {
+		return zero
+	}
+	return *p
+}
+
 func DeleteOrderHandler(client orderpbv1.OrderServiceClient) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id := c.Param("id")
@@ -222,6 +346,9 @@ func DeleteOrderHandler(client orderpbv1.OrderServiceClient) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, errorResponse("неверный формат id", nil))
 			return
 		}
+		if _, ok := orderForUpdate(c, client, id, false); !ok {
+			return
+		}
 		if _, err := client.DeleteOrder(c, &orderpbv1.DeleteOrderRequest{Id: id}); err != nil {
 			c.JSON(http.StatusInternalServerError, errorResponse("внутренняя ошибка сервера", nil))
 			return
@@ -232,15 +359,17 @@ func DeleteOrderHandler(client orderpbv1.OrderServiceClient) gin.HandlerFunc {
 
 func GetMyOrdersHandler(client orderpbv1.OrderServiceClient) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		claims, ok := auth.CurrentUser(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, errorResponse("требуется авторизация", nil))
+			return
+		}
+
 		var req getMyOrdersRequest
 		if err := c.ShouldBindQuery(&req); err != nil {
 			c.JSON(http.StatusBadRequest, errorResponse("некорректные параметры", nil))
 			return
 		}
-		if _, err := uuid.Parse(req.UserId); err != nil {
-			c.JSON(http.StatusBadRequest, errorResponse("неверный формат user_id", nil))
-			return
-		}
 		for _, id := range req.CategoriesIds {
 			if _, err := uuid.Parse(id); err != nil {
 				c.JSON(http.StatusBadRequest, errorResponse("неверный формат categories_ids", nil))
@@ -249,7 +378,7 @@ func GetMyOrdersHandler(client orderpbv1.OrderServiceClient) gin.HandlerFunc {
 		}
 
 		resp, err := client.GetMyOrders(c, &orderpbv1.GetMyOrdersRequest{
-			UserId:        req.UserId,
+			UserId:        claims.UserID,
 			Status:        req.Status,
 			CategoriesIds: req.CategoriesIds,
 		})
@@ -266,18 +395,14 @@ func GetMyOrdersHandler(client orderpbv1.OrderServiceClient) gin.HandlerFunc {
 
 func GetMyFinishedOrdersHandler(client orderpbv1.OrderServiceClient) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var req getMyFinishedOrdersRequest
-		if err := c.ShouldBindQuery(&req); err != nil {
-			c.JSON(http.StatusBadRequest, errorResponse("некорректные параметры", nil))
-			return
-		}
-		if _, err := uuid.Parse(req.UserId); err != nil {
-			c.JSON(http.StatusBadRequest, errorResponse("неверный формат user_id", nil))
+		claims, ok := auth.CurrentUser(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, errorResponse("требуется авторизация", nil))
 			return
 		}
 
 		resp, err := client.GetMyFinishedOrders(c, &orderpbv1.GetMyFinishedOrdersRequest{
-			UserId: req.UserId,
+			UserId: claims.UserID,
 		})
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, errorResponse("внутренняя ошибка сервера", nil))
@@ -290,12 +415,325 @@ func GetMyFinishedOrdersHandler(client orderpbv1.OrderServiceClient) gin.Handler
 	}
 }
 
-func RegisterHandlers(r gin.IRouter, client orderpbv1.OrderServiceClient) {
-	r.POST("/", CreateOrderHandler(client))
+const defaultNearbyRadiusKm = 10
+
+func GetNearbyOrdersHandler(client orderpbv1.OrderServiceClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req getNearbyOrdersRequest
+		if err := c.ShouldBindQuery(&req); err != nil {
+			errs := make(map[string]string)
+			if ve, ok := err.(validator.ValidationErrors); ok {
+				for _, fe := range ve {
+					switch fe.Field() {
+					case "Lat":
+						errs["lat"] = "широта обязательна"
+					case "Lon":
+						errs["lon"] = "долгота обязательна"
+					case "RadiusKm":
+						errs["radius_km"] = "радиус должен быть больше 0 и не больше 500 км"
+					case "CategoryId":
+						errs["category_id"] = "неверный формат category_id"
+					case "Status":
+						errs["status"] = "статус должен быть open, assigned, in_progress, completed или cancelled"
+					}
+				}
+			} else {
+				errs["query"] = "некорректные параметры запроса"
+			}
+			c.JSON(http.StatusBadRequest, errorResponse("ошибка валидации", errs))
+			return
+		}
+
+		lat, ok := parseLatitude(req.Lat)
+		if !ok {
+			c.JSON(http.StatusBadRequest, errorResponse("ошибка валидации", map[string]string{
+				"lat": "широта должна быть числом от -90 до 90",
+			}))
+			return
+		}
+		lon, ok := parseLongitude(req.Lon)
+		if !ok {
+			c.JSON(http.StatusBadRequest, errorResponse("ошибка валидации", map[string]string{
+				"lon": "долгота должна быть числом от -180 до 180",
+			}))
+			return
+		}
+		if req.RadiusKm == 0 {
+			req.RadiusKm = defaultNearbyRadiusKm
+		}
+
+		categoriesIds := []string{}
+		if req.CategoryId != "" {
+			categoriesIds = append(categoriesIds, req.CategoryId)
+		}
+
+		// сервис заказов не умеет искать по координатам, поэтому
+		// забираем подходящие заказы и фильтруем их на стороне шлюза
+		resp, err := client.GetOrders(c.Request.Context(), &orderpbv1.GetOrdersRequest{
+			CategoriesIds: categoriesIds,
+			Status:        req.Status,
+			ClientId:      uuid.Nil.String(),
+			MasterId:      uuid.Nil.String(),
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, errorResponse("внутренняя ошибка сервера", nil))
+			return
+		}
+
+		orders := make([]NearbyOrder, 0, len(resp.Orders))
+		for _, o := range resp.Orders {
+			oLat, okLat := parseLatitude(o.Latitude)
+			oLon, okLon := parseLongitude(o.Longitude)
+			if !okLat || !okLon {
+				continue
+			}
+			d := distanceKm(lat, lon, oLat, oLon)
+			if d > req.RadiusKm {
+				continue
+			}
+			orders = append(orders, NearbyOrder{Order: o, DistanceKm: math.Round(d*100) / 100})
+		}
+		sort.SliceStable(orders, func(i, j int) bool {
+			return orders[i].DistanceKm < orders[j].DistanceKm
+		})
+
+		c.JSON(http.StatusOK, NearbyOrdersResponse{
+			Response: Response{Success: true, Message: "успешно"},
+			Orders:   orders,
+		})
+	}
+}
+
+// loadOrder получает заказ по id. При ошибке ответ уже записан.
+func loadOrder(c *gin.Context, client orderpbv1.OrderServiceClient, id string) (*commonpbv1.OrderData, bool) {
+	resp, err := client.GetOrderById(c.Request.Context(), &orderpbv1.GetOrderByIdRequest{Id: id})
+	if err != nil {
+		if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
+			c.JSON(http.StatusNotFound, errorResponse(st.Message(), nil))
+		} else {
+			c.JSON(http.StatusInternalServerError, errorResponse("внутренняя ошибка сервера", nil))
+		}
+		return nil, false
+	}
+	return resp.Order, true
+}
+
+// orderForUpdate загружает заказ перед изменением, если он нужен: для If-Match
+// или, при needed, для проверки смены статуса. Заказ загружается один раз
+// на запрос; nil — загружать было не нужно. При ошибке ответ уже записан.
+func orderForUpdate(c *gin.Context, client orderpbv1.OrderServiceClient, id string, needed bool) (*commonpbv1.OrderData, bool) {
+	if !needed && !util.HasIfMatch(c) {
+		return nil, true
+	}
+	current, ok := loadOrder(c, client, id)
+	if !ok || !checkIfMatch(c, current) {
+		return nil, false
+	}
+	return current, true
+}
+
+// checkIfMatch сверяет If-Match с текущей версией заказа. Без заголовка
+// проверка не выполняется. При ошибке ответ уже записан.
+func checkIfMatch(c *gin.Context, current *commonpbv1.OrderData) bool {
+	if !util.IfMatch(c, util.ETag(current)) {
+		c.JSON(http.StatusPreconditionFailed, errorResponse("заказ был изменён, обновите данные и повторите запрос", nil))
+		return false
+	}
+	return true
+}
+
+// checkTransition проверяет, что пользователь может перевести заказ o в статус to.
+// При ошибке ответ уже записан.
+func checkTransition(c *gin.Context, o *commonpbv1.OrderData, claims *jwt.Claims, to string) bool {
+	if !canTransition(o.GetStatus(), to) {
+		c.JSON(http.StatusConflict, errorResponse(
+			fmt.Sprintf("недопустимый переход статуса: %s → %s", o.GetStatus(), to), nil))
+		return false
+	}
+	if !canPerform(o.GetStatus(), to, orderActor(o, claims.UserID, claims.Role)) {
+		c.JSON(http.StatusForbidden, errorResponse("недостаточно прав для изменения статуса", nil))
+		return false
+	}
+	return true
+}
+
+// authorizeTransition загружает заказ, сверяет If-Match и проверяет, что
+// текущий пользователь может перевести заказ в статус to. При ошибке ответ
+// уже записан.
+func authorizeTransition(c *gin.Context, client orderpbv1.OrderServiceClient, id, to string) (*commonpbv1.OrderData, *jwt.Claims, bool) {
+	claims, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse("требуется авторизация", nil))
+		return nil, nil, false
+	}
+	o, ok := loadOrder(c, client, id)
+	if !ok || !checkIfMatch(c, o) || !checkTransition(c, o, claims, to) {
+		return nil, nil, false
+	}
+	return o, claims, true
+}
+
+// authorizeStatusChange проверяет смену статуса заказа current через PUT/PATCH
+// по тем же правилам, что и отдельные эндпоинты. Повторная установка текущего
+// статуса разрешена. Назначить заказ, как и через /assign, можно только
+// вместе с мастером: withMaster сообщает, что master_id есть в запросе.
+// При ошибке ответ уже записан.
+func authorizeStatusChange(c *gin.Context, current *commonpbv1.OrderData, to string, withMaster bool) bool {
+	claims, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse("требуется авторизация", nil))
+		return false
+	}
+	if current.GetStatus() == to {
+		return true
+	}
+	if !checkTransition(c, current, claims, to) {
+		return false
+	}
+	if to == StatusAssigned && !withMaster {
+		c.JSON(http.StatusBadRequest, errorResponse("ошибка валидации", map[string]string{
+			"master_id": "мастер обязателен для статуса assigned",
+		}))
+		return false
+	}
+	return true
+}
+
+// checkAssignee проверяет, что пользователь masterID существует и он
+// мастер. При ошибке ответ уже записан.
+func checkAssignee(c *gin.Context, userClient userv1.UserServiceClient, masterID string) bool {
+	resp, err := userClient.GetUserById(c.Request.Context(), &userv1.GetUserByIdRequest{UserId: masterID})
+	if err != nil {
+		if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
+			c.JSON(http.StatusBadRequest, errorResponse("ошибка валидации", map[string]string{
+				"master_id": "мастер не найден",
+			}))
+		} else {
+			c.JSON(http.StatusInternalServerError, errorResponse("внутренняя ошибка сервера", nil))
+		}
+		return false
+	}
+	if resp.User.GetRole() != actorMaster {
+		c.JSON(http.StatusBadRequest, errorResponse("ошибка валидации", map[string]string{
+			"master_id": "пользователь не является мастером",
+		}))
+		return false
+	}
+	return true
+}
+
+// changeStatusHandler возвращает хендлер, переводящий заказ в статус to.
+func changeStatusHandler(client orderpbv1.OrderServiceClient, to string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		if _, err := uuid.Parse(id); err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse("неверный формат id", nil))
+			return
+		}
+
+		if _, _, ok := authorizeTransition(c, client, id, to); !ok {
+			return
+		}
+
+		resp, err := client.UpdateOrder(util.WithFieldMask(c.Request.Context(), "status"), &orderpbv1.UpdateOrderRequest{Id: id, Status: to})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, errorResponse("внутренняя ошибка сервера", nil))
+			return
+		}
+		util.SetETag(c, util.ETag(resp.Order))
+		c.JSON(http.StatusOK, OrderResponse{
+			Response: Response{Success: true, Message: "успешно"},
+			Order:    resp.Order,
+		})
+	}
+}
+
+// AssignOrderHandler назначает на заказ мастера. Мастер может взять заказ
+// только себе, клиент и администратор назначают мастера из master_id.
+func AssignOrderHandler(client orderpbv1.OrderServiceClient, userClient userv1.UserServiceClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		if _, err := uuid.Parse(id); err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse("неверный формат id", nil))
+			return
+		}
+
+		var req assignOrderRequest
+		if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+			c.JSON(http.StatusBadRequest, errorResponse("ошибка валидации", orderValidationErrors(err)))
+			return
+		}
+
+		_, claims, ok := authorizeTransition(c, client, id, StatusAssigned)
+		if !ok {
+			return
+		}
+		// мастер может назначить заказ только на себя
+		if claims.Role == actorMaster {
+			req.MasterId = claims.UserID
+		}
+		if req.MasterId == "" {
+			c.JSON(http.StatusBadRequest, errorResponse("ошибка валидации", map[string]string{
+				"master_id": "мастер обязателен",
+			}))
+			return
+		}
+		if claims.Role != actorMaster && !checkAssignee(c, userClient, req.MasterId) {
+			return
+		}
+
+		ctx := util.WithFieldMask(c.Request.Context(), "status", "master_id")
+		resp, err := client.UpdateOrder(ctx, &orderpbv1.UpdateOrderRequest{
+			Id:       id,
+			Status:   StatusAssigned,
+			MasterId: req.MasterId,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, errorResponse("внутренняя ошибка сервера", nil))
+			return
+		}
+		util.SetETag(c, util.ETag(resp.Order))
+		c.JSON(http.StatusOK, OrderResponse{
+			Response: Response{Success: true, Message: "успешно"},
+			Order:    resp.Order,
+		})
+	}
+}
+
+func StartOrderHandler(client orderpbv1.OrderServiceClient) gin.HandlerFunc {
+	return changeStatusHandler(client, StatusInProgress)
+}
+
+func CompleteOrderHandler(client orderpbv1.OrderServiceClient) gin.HandlerFunc {
+	return changeStatusHandler(client, StatusCompleted)
+}
+
+func CancelOrderHandler(client orderpbv1.OrderServiceClient) gin.HandlerFunc {
+	return changeStatusHandler(client, StatusCancelled)
+}
+
+func RegisterHandlers(
+	r gin.IRouter,
+	client orderpbv1.OrderServiceClient,
+	userClient userv1.UserServiceClient,
+	categoryClient categorypbv1.CategoryServiceClient,
+	idem *idempotency.Store,
+) {
+	r.POST("/", idempotency.Middleware(idem), CreateOrderHandler(client))
 	r.GET("/", GetOrdersHandler(client))
-	r.GET("/:id", GetOrderHandler(client))
-	r.PUT("/:id", UpdateOrderHandler(client))
+	r.GET("/nearby", GetNearbyOrdersHandler(client))
+	r.GET("/:id", GetOrderHandler(client, userClient, categoryClient))
+	r.PUT("/:id", UpdateOrderHandler(client, userClient))
+	r.PATCH("/:id", PatchOrderHandler(client, userClient))
 	r.DELETE("/:id", DeleteOrderHandler(client))
-	r.GET("/my", GetMyOrdersHandler(client))
-	r.GET("/my/finished", GetMyFinishedOrdersHandler(client))
+	r.POST("/:id/assign", AssignOrderHandler(client, userClient))
+	r.POST("/:id/start", StartOrderHandler(client))
+	r.POST("/:id/complete", CompleteOrderHandler(client))
+	r.POST("/:id/cancel", CancelOrderHandler(client))
+}
+
+// RegisterMeHandlers вешает маршруты заказов текущего пользователя (/me).
+func RegisterMeHandlers(r gin.IRouter, client orderpbv1.OrderServiceClient) {
+	r.GET("/orders", GetMyOrdersHandler(client))
+	r.GET("/orders/finished", GetMyFinishedOrdersHandler(client))
 }
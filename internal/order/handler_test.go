@@ -0,0 +1,220 @@
+// internal/order/handler_test.go
+package order
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/idempotency"
+	"github.com/Ostap00034/course-work-backend-api-gateway/util"
+	commonpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/common/v1"
+	orderpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/order/v1"
+	userv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/user/v1"
+	"github.com/Ostap00034/course-work-backend-auth-service/util/jwt"
+)
+
+const (
+	testOrderID  = "00000000-0000-0000-0000-00000000e001"
+	testClientID = "00000000-0000-0000-0000-00000000c001"
+	testMasterID = "00000000-0000-0000-0000-00000000a001"
+	testOtherID  = "00000000-0000-0000-0000-00000000b001"
+)
+
+// fakeOrders хранит один заказ и запоминает маску полей последнего
+// UpdateOrder. Как и сервис, меняет только поля из маски.
+type fakeOrders struct {
+	orderpbv1.OrderServiceClient
+	mu    sync.Mutex
+	order *commonpbv1.OrderData
+	loads int
+	mask  string
+}
+
+func newFakeOrders(st string) *fakeOrders {
+	return &fakeOrders{order: &commonpbv1.OrderData{
+		Id:     testOrderID,
+		Title:  "Починить кран",
+		Status: st,
+		Client: &commonpbv1.UserData{Id: testClientID},
+	}}
+}
+
+func (f *fakeOrders) GetOrderById(_ context.Context, req *orderpbv1.GetOrderByIdRequest, _ ...grpc.CallOption) (*orderpbv1.GetOrderByIdResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.loads++
+	if req.Id != f.order.Id {
+		return nil, status.Error(codes.NotFound, "заказ не найден")
+	}
+	return &orderpbv1.GetOrderByIdResponse{Order: proto.Clone(f.order).(*commonpbv1.OrderData)}, nil
+}
+
+func (f *fakeOrders) UpdateOrder(ctx context.Context, req *orderpbv1.UpdateOrderRequest, _ ...grpc.CallOption) (*orderpbv1.GetOrderByIdResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	md, _ := metadata.FromOutgoingContext(ctx)
+	f.mask = strings.Join(md.Get(util.FieldMaskMetadataKey), ";")
+	o := proto.Clone(f.order).(*commonpbv1.OrderData)
+	for _, field := range strings.Split(f.mask, ",") {
+		switch field {
+		case "status":
+			o.Status = req.Status
+		case "master_id":
+			o.Master = &commonpbv1.UserData{Id: req.MasterId}
+		case "title":
+			o.Title = req.Title
+		}
+	}
+	f.order = o
+	return &orderpbv1.GetOrderByIdResponse{Order: o}, nil
+}
+
+// fakeUsers знает клиентов testClientID, testOtherID и мастера testMasterID.
+type fakeUsers struct {
+	userv1.UserServiceClient
+}
+
+func (fakeUsers) GetUserById(_ context.Context, req *userv1.GetUserByIdRequest, _ ...grpc.CallOption) (*userv1.GetUserByIdResponse, error) {
+	switch req.UserId {
+	case testMasterID:
+		return &userv1.GetUserByIdResponse{User: &commonpbv1.UserData{Id: req.UserId, Role: "master"}}, nil
+	case testClientID, testOtherID:
+		return &userv1.GetUserByIdResponse{User: &commonpbv1.UserData{Id: req.UserId, Role: "client"}}, nil
+	}
+	return nil, status.Error(codes.NotFound, "пользователь не найден")
+}
+
+func newOrderRouter(orders *fakeOrders) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterHandlers(r.Group("/orders"), orders, fakeUsers{}, nil, idempotency.NewStore(time.Minute))
+	return r
+}
+
+// serve выполняет запрос от имени пользователя с ролью role.
+func serve(t *testing.T, r *gin.Engine, method, target, body, userID, role string, header ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	token, err := jwt.GenerateToken(jwt.NewClaims(userID, role, time.Now().Add(time.Hour)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.AddCookie(&http.Cookie{Name: "token", Value: token})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+const orderBody = `{"title":"Починить кран","description":"Течёт","price":100,"address":"Москва",` +
+	`"longitude":"37.6","latitude":"55.7","category_id":"00000000-0000-0000-0000-0000000000aa",` +
+	`"client_id":"` + testClientID + `"`
+
+func TestStatusChangeEndpoints(t *testing.T) {
+	target := "/orders/" + testOrderID
+	tests := []struct {
+		name, from           string
+		method, path, body   string
+		userID, role         string
+		code                 int
+		mask, status, master string
+	}{
+		{"client assigns a master", StatusOpen, "POST", "/assign", `{"master_id":"` + testMasterID + `"}`,
+			testClientID, "client", http.StatusOK, "master_id,status", StatusAssigned, testMasterID},
+		{"master takes the order", StatusOpen, "POST", "/assign", "",
+			testMasterID, "master", http.StatusOK, "master_id,status", StatusAssigned, testMasterID},
+		{"assignee is not a master", StatusOpen, "POST", "/assign", `{"master_id":"` + testOtherID + `"}`,
+			testClientID, "client", http.StatusBadRequest, "", StatusOpen, ""},
+		{"assignee does not exist", StatusOpen, "POST", "/assign", `{"master_id":"00000000-0000-0000-0000-0000000000ff"}`,
+			testClientID, "client", http.StatusBadRequest, "", StatusOpen, ""},
+		{"client starts work", StatusAssigned, "POST", "/start", "",
+			testClientID, "client", http.StatusForbidden, "", StatusAssigned, ""},
+		{"client cancels", StatusOpen, "POST", "/cancel", "",
+			testClientID, "client", http.StatusOK, "status", StatusCancelled, ""},
+		{"complete an open order", StatusOpen, "POST", "/complete", "",
+			testClientID, "client", http.StatusConflict, "", StatusOpen, ""},
+		{"PUT assigned without a master", StatusOpen, "PUT", "", orderBody + `,"status":"assigned"}`,
+			testClientID, "client", http.StatusBadRequest, "", StatusOpen, ""},
+		{"PUT assigned to a client", StatusOpen, "PUT", "", orderBody + `,"status":"assigned","master_id":"` + testOtherID + `"}`,
+			testClientID, "client", http.StatusBadRequest, "", StatusOpen, ""},
+		{"PUT assigned with a master", StatusOpen, "PUT", "", orderBody + `,"status":"assigned","master_id":"` + testMasterID + `"}`,
+			testClientID, "client", http.StatusOK, "address,category_id,client_id,description,latitude,longitude,master_id,price,status,title", StatusAssigned, testMasterID},
+		{"PATCH assigned without a master", StatusOpen, "PATCH", "", `{"status":"assigned"}`,
+			testClientID, "client", http.StatusBadRequest, "", StatusOpen, ""},
+		{"PATCH keeps the assigned status", StatusAssigned, "PATCH", "", `{"status":"assigned","title":"Новый кран"}`,
+			testClientID, "client", http.StatusOK, "status,title", StatusAssigned, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orders := newFakeOrders(tt.from)
+			r := newOrderRouter(orders)
+			var header []string
+			if tt.method == "PATCH" {
+				header = []string{"Content-Type", "application/merge-patch+json"}
+			}
+			w := serve(t, r, tt.method, target+tt.path, tt.body, tt.userID, tt.role, header...)
+			if w.Code != tt.code {
+				t.Fatalf("got %d %s, want %d", w.Code, w.Body, tt.code)
+			}
+			if orders.mask != tt.mask {
+				t.Errorf("field mask %q, want %q", orders.mask, tt.mask)
+			}
+			if orders.order.GetStatus() != tt.status || orders.order.GetMaster().GetId() != tt.master {
+				t.Errorf("order is %s with master %q, want %s with %q",
+					orders.order.GetStatus(), orders.order.GetMaster().GetId(), tt.status, tt.master)
+			}
+			if tt.code == http.StatusOK && w.Header().Get("ETag") != util.ETag(orders.order) {
+				t.Errorf("ETag %q, want %q", w.Header().Get("ETag"), util.ETag(orders.order))
+			}
+		})
+	}
+}
+
+func TestStatusChangeIfMatch(t *testing.T) {
+	target := "/orders/" + testOrderID
+	etag := util.ETag(newFakeOrders(StatusOpen).order)
+	tests := []struct {
+		name, method, path, body, ifMatch string
+		code                              int
+	}{
+		{"PATCH with current version", "PATCH", "", `{"status":"cancelled"}`, etag, http.StatusOK},
+		{"PATCH with stale version", "PATCH", "", `{"status":"cancelled"}`, `"stale"`, http.StatusPreconditionFailed},
+		{"PUT with current version", "PUT", "", orderBody + `,"status":"cancelled"}`, etag, http.StatusOK},
+		{"cancel with current version", "POST", "/cancel", "", etag, http.StatusOK},
+		{"cancel with stale version", "POST", "/cancel", "", `"stale"`, http.StatusPreconditionFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orders := newFakeOrders(StatusOpen)
+			r := newOrderRouter(orders)
+			contentType := "application/json"
+			if tt.method == "PATCH" {
+				contentType = "application/merge-patch+json"
+			}
+			w := serve(t, r, tt.method, target+tt.path, tt.body, testClientID, "client",
+				"Content-Type", contentType, "If-Match", tt.ifMatch)
+			if w.Code != tt.code {
+				t.Fatalf("got %d %s, want %d", w.Code, w.Body, tt.code)
+			}
+			// If-Match и проверка перехода используют один загруженный заказ
+			if orders.loads != 1 {
+				t.Fatalf("order loaded %d times, want 1", orders.loads)
+			}
+		})
+	}
+}
@@ -2,42 +2,75 @@
 package order
 
 type createOrderRequest struct {
-	Title       string  `json:"title" binding:"required"`
-	Description string  `json:"description" binding:"required"`
-	Price       float32 `json:"price" binding:"required"`
-	Address     string  `json:"address" binding:"required"`
-	Longitude   string  `json:"longitude" binding:"required"`
-	Latitude    string  `json:"latitude" binding:"required"`
-	CategoryId  string  `json:"category_id"`
-	ClientId    string  `json:"client_id" binding:"required"`
+	Title       string  `json:"title" binding:"required,min=3,max=255"`
+	Description string  `json:"description" binding:"required,max=5000"`
+	Price       float32 `json:"price" binding:"required,gte=0.01,lte=10000000,price_precision"`
+	Address     string  `json:"address" binding:"required,max=500"`
+	Longitude   string  `json:"longitude" binding:"required,longitude"`
+	Latitude    string  `json:"latitude" binding:"required,latitude"`
+	CategoryId  string  `json:"category_id" binding:"required,uuid"`
+	ClientId    string  `json:"client_id" binding:"required,uuid"`
 }
 
 type getOrdersRequest struct {
 	CategoriesIds []string `form:"categories_ids"`
-	Status        string   `form:"status"`
+	Status        string   `form:"status" binding:"omitempty,oneof=open assigned in_progress completed cancelled"`
 	ClientId      string   `form:"client_id"`
 	MasterId      string   `form:"master_id"`
 }
 
+// updateOrderRequest — полная замена заказа (PUT). Статус и мастер
+// меняются только если переданы.
 type updateOrderRequest struct {
-	Title       string  `json:"title,omitempty" binding:"omitempty"`
-	Description string  `json:"description,omitempty" binding:"omitempty"`
-	Price       float32 `json:"price,omitempty" binding:"omitempty"`
-	Address     string  `json:"address,omitempty" binding:"omitempty"`
-	Longitude   string  `json:"longitude,omitempty" binding:"omitempty"`
-	Latitude    string  `json:"latitude,omitempty" binding:"omitempty"`
-	Status      string  `json:"status,omitempty" binding:"omitempty"`
-	CategoryId  string  `json:"category_id,omitempty" binding:"omitempty"`
-	ClientId    string  `json:"client_id,omitempty" binding:"omitempty"`
-	MasterId    string  `json:"master_id,omitempty" binding:"omitempty"`
+	Title       string  `json:"title" binding:"required,min=3,max=255"`
+	Description string  `json:"description" binding:"required,max=5000"`
+	Price       float32 `json:"price" binding:"required,gte=0.01,lte=10000000,price_precision"`
+	Address     string  `json:"address" binding:"required,max=500"`
+	Longitude   string  `json:"longitude" binding:"required,longitude"`
+	Latitude    string  `json:"latitude" binding:"required,latitude"`
+	Status      string  `json:"status,omitempty" binding:"omitempty,oneof=open assigned in_progress completed cancelled"`
+	CategoryId  string  `json:"category_id" binding:"required,uuid"`
+	ClientId    string  `json:"client_id" binding:"required,uuid"`
+	MasterId    string  `json:"master_id,omitempty" binding:"omitempty,uuid"`
+}
+
+// patchOrderRequest — частичное изменение заказа (PATCH, JSON Merge Patch).
+type patchOrderRequest struct {
+	Title       *string  `json:"title" binding:"omitempty,min=3,max=255"`
+	Description *string  `json:"description" binding:"omitempty,max=5000"`
+	Price       *float32 `json:"price" binding:"omitempty,gte=0.01,lte=10000000,price_precision"`
+	Address     *string  `json:"address" binding:"omitempty,max=500"`
+	Longitude   *string  `json:"longitude" binding:"omitempty,longitude"`
+	Latitude    *string  `json:"latitude" binding:"omitempty,latitude"`
+	Status      *string  `json:"status" binding:"omitempty,oneof=open assigned in_progress completed cancelled"`
+	CategoryId  *string  `json:"category_id" binding:"omitempty,uuid"`
+	ClientId    *string  `json:"client_id" binding:"omitempty,uuid"`
+	MasterId    *string  `json:"master_id" binding:"omitempty,uuid"`
 }
 
+// Поля заказа, которые можно передавать в маске изменений.
+var (
+	orderReplaceFields = []string{
+		"title", "description", "price", "address", "longitude", "latitude", "category_id", "client_id",
+	}
+	orderPatchFields = append(append([]string{}, orderReplaceFields...), "status", "master_id")
+	// orderNullableFields можно очистить, передав null
+	orderNullableFields = map[string]bool{"master_id": true}
+)
+
 type getMyOrdersRequest struct {
-	UserId        string   `form:"user_id" binding:"required"`
 	Status        string   `form:"status"`
 	CategoriesIds []string `form:"categories_ids"`
 }
 
-type getMyFinishedOrdersRequest struct {
-	UserId string `form:"user_id" binding:"required"`
+type getNearbyOrdersRequest struct {
+	Lat        string  `form:"lat" binding:"required"`
+	Lon        string  `form:"lon" binding:"required"`
+	RadiusKm   float64 `form:"radius_km" binding:"omitempty,gt=0,lte=500"`
+	CategoryId string  `form:"category_id" binding:"omitempty,uuid"`
+	Status     string  `form:"status" binding:"omitempty,oneof=open assigned in_progress completed cancelled"`
+}
+
+type assignOrderRequest struct {
+	MasterId string `json:"master_id" binding:"omitempty,uuid"`
 }
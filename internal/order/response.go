@@ -20,6 +20,17 @@ func successResponse(msg string) Response {
 type OrderResponse struct {
 	Response
 	Order *commonpbv1.OrderData `json:"order,omitempty"`
+	// Expanded заполняется, если запрошен ?expand=
+	Expanded *ExpandedOrder `json:"expanded,omitempty"`
+	// ExpandErrors — поля expand, которые не удалось загрузить
+	ExpandErrors map[string]string `json:"expand_errors,omitempty"`
+}
+
+// ExpandedOrder — связанные с заказом ресурсы из других сервисов.
+type ExpandedOrder struct {
+	Client   *commonpbv1.UserData     `json:"client,omitempty"`
+	Master   *commonpbv1.UserData     `json:"master,omitempty"`
+	Category *commonpbv1.CategoryData `json:"category,omitempty"`
 }
 
 type OrdersResponse struct {
@@ -31,3 +42,13 @@ type MyOrdersResponse struct {
 	Response
 	Orders []*commonpbv1.OrderData `json:"orders,omitempty"`
 }
+
+type NearbyOrder struct {
+	Order      *commonpbv1.OrderData `json:"order"`
+	DistanceKm float64               `json:"distance_km"`
+}
+
+type NearbyOrdersResponse struct {
+	Response
+	Orders []NearbyOrder `json:"orders"`
+}
@@ -0,0 +1,88 @@
+// internal/order/status.go
+package order
+
+import (
+	commonpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/common/v1"
+)
+
+// Статусы заказа.
+const (
+	StatusOpen       = "open"
+	StatusAssigned   = "assigned"
+	StatusInProgress = "in_progress"
+	StatusCompleted  = "completed"
+	StatusCancelled  = "cancelled"
+)
+
+// IsActiveStatus сообщает, что заказ ещё не завершён и не отменён.
+func IsActiveStatus(s string) bool {
+	return s != StatusCompleted && s != StatusCancelled
+}
+
+// Роли участников заказа относительно конкретного заказа.
+const (
+	actorClient = "client" // владелец заказа
+	actorMaster = "master" // назначенный мастер (или мастер, берущий открытый заказ)
+	actorAdmin  = "admin"
+)
+
+// transitions описывает допустимые переходы статусов и кто может их выполнить.
+var transitions = map[string]map[string][]string{
+	StatusOpen: {
+		StatusAssigned:  {actorClient, actorMaster, actorAdmin},
+		StatusCancelled: {actorClient, actorAdmin},
+	},
+	StatusAssigned: {
+		StatusInProgress: {actorMaster, actorAdmin},
+		StatusCancelled:  {actorClient, actorMaster, actorAdmin},
+	},
+	StatusInProgress: {
+		StatusCompleted: {actorClient, actorMaster, actorAdmin},
+		StatusCancelled: {actorAdmin},
+	},
+}
+
+// canTransition сообщает, существует ли переход from → to.
+func canTransition(from, to string) bool {
+	_, ok := transitions[from][to]
+	return ok
+}
+
+// canPerform сообщает, может ли actor выполнить переход from → to.
+func canPerform(from, to, actor string) bool {
+	for _, a := range transitions[from][to] {
+		if a == actor {
+			return true
+		}
+	}
+	return false
+}
+
+// orderActor определяет роль пользователя по отношению к заказу.
+// Мастер, ещё не назначенный на открытый заказ, считается мастером,
+// чтобы он мог взять заказ себе.
+func orderActor(o *commonpbv1.OrderData, userID, role string) string {
+	switch {
+	case role == actorAdmin:
+		return actorAdmin
+	case o.GetClient().GetId() == userID:
+		return actorClient
+	case o.GetMaster().GetId() == userID:
+		return actorMaster
+	case role == actorMaster && o.GetStatus() == StatusOpen:
+		return actorMaster
+	}
+	return ""
+}
+
+// CanFollow сообщает, может ли пользователь следить за заказом и его офферами:
+// это клиент заказа, назначенный мастер, любой мастер, пока заказ открыт,
+// или администратор.
+//
+// Проверить, что мастер работает в категории заказа, шлюз не может: в
+// UserData и UserService нет специализаций мастеров. Поэтому открытый заказ
+// видят все мастера — так же, как они видят его в списке заказов. Когда
+// специализации появятся в спецификации, проверку нужно добавить сюда.
+func CanFollow(o *commonpbv1.OrderData, userID, role string) bool {
+	return orderActor(o, userID, role) != ""
+}
@@ -0,0 +1,121 @@
+// internal/order/status_test.go
+package order
+
+import (
+	"testing"
+
+	commonpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/common/v1"
+)
+
+var allStatuses = []string{StatusOpen, StatusAssigned, StatusInProgress, StatusCompleted, StatusCancelled}
+
+func TestTransitionMatrix(t *testing.T) {
+	// кто может выполнить каждый допустимый переход; остальных переходов нет
+	want := map[[2]string][]string{
+		{StatusOpen, StatusAssigned}:        {actorClient, actorMaster, actorAdmin},
+		{StatusOpen, StatusCancelled}:       {actorClient, actorAdmin},
+		{StatusAssigned, StatusInProgress}:  {actorMaster, actorAdmin},
+		{StatusAssigned, StatusCancelled}:   {actorClient, actorMaster, actorAdmin},
+		{StatusInProgress, StatusCompleted}: {actorClient, actorMaster, actorAdmin},
+		{StatusInProgress, StatusCancelled}: {actorAdmin},
+	}
+	for _, from := range allStatuses {
+		for _, to := range allStatuses {
+			actors, exists := want[[2]string{from, to}]
+			if got := canTransition(from, to); got != exists {
+				t.Errorf("canTransition(%s, %s) = %v, want %v", from, to, got, exists)
+			}
+			for _, actor := range []string{actorClient, actorMaster, actorAdmin, ""} {
+				allowed := false
+				for _, a := range actors {
+					allowed = allowed || a == actor
+				}
+				if got := canPerform(from, to, actor); got != allowed {
+					t.Errorf("canPerform(%s, %s, %q) = %v, want %v", from, to, actor, got, allowed)
+				}
+			}
+		}
+	}
+}
+
+func TestFinishedStatusesAreFinal(t *testing.T) {
+	for _, from := range []string{StatusCompleted, StatusCancelled} {
+		if IsActiveStatus(from) {
+			t.Errorf("%s is active", from)
+		}
+		for _, to := range allStatuses {
+			if canTransition(from, to) {
+				t.Errorf("%s → %s is allowed", from, to)
+			}
+		}
+	}
+}
+
+func TestOrderActor(t *testing.T) {
+	const clientID, masterID, otherID = "client", "master", "other"
+	order := func(status string, master bool) *commonpbv1.OrderData {
+		o := &commonpbv1.OrderData{Status: status, Client: &commonpbv1.UserData{Id: clientID}}
+		if master {
+			o.Master = &commonpbv1.UserData{Id: masterID}
+		}
+		return o
+	}
+	tests := []struct {
+		name         string
+		o            *commonpbv1.OrderData
+		userID, role string
+		want         string
+	}{
+		{"owner", order(StatusOpen, false), clientID, "client", actorClient},
+		{"assigned master", order(StatusAssigned, true), masterID, "master", actorMaster},
+		{"admin", order(StatusAssigned, true), otherID, "admin", actorAdmin},
+		{"master takes an open order", order(StatusOpen, false), otherID, "master", actorMaster},
+		{"master of someone else's assigned order", order(StatusAssigned, true), otherID, "master", ""},
+		{"other client", order(StatusOpen, false), otherID, "client", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := orderActor(tt.o, tt.userID, tt.role); got != tt.want {
+				t.Fatalf("orderActor = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCanFollow — кто видит заказ и его офферы в каждом статусе. Свободный
+// мастер видит только открытые заказы: проверять его категорию не по чему,
+// в данных пользователя нет специализаций.
+func TestCanFollow(t *testing.T) {
+	const clientID, masterID, otherID = "client", "master", "other"
+	followers := map[string][]string{
+		StatusOpen:       {"owner", "assigned master", "free master", "admin"},
+		StatusAssigned:   {"owner", "assigned master", "admin"},
+		StatusInProgress: {"owner", "assigned master", "admin"},
+		StatusCompleted:  {"owner", "assigned master", "admin"},
+		StatusCancelled:  {"owner", "assigned master", "admin"},
+	}
+	users := []struct{ name, userID, role string }{
+		{"owner", clientID, "client"},
+		{"assigned master", masterID, "master"},
+		{"free master", otherID, "master"},
+		{"other client", otherID, "client"},
+		{"admin", otherID, "admin"},
+		{"anonymous", "", ""},
+	}
+	for _, st := range allStatuses {
+		o := &commonpbv1.OrderData{
+			Status: st,
+			Client: &commonpbv1.UserData{Id: clientID},
+			Master: &commonpbv1.UserData{Id: masterID},
+		}
+		for _, u := range users {
+			want := false
+			for _, name := range followers[st] {
+				want = want || name == u.name
+			}
+			if got := CanFollow(o, u.userID, u.role); got != want {
+				t.Errorf("%s: CanFollow(%s) = %v, want %v", st, u.name, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// internal/order/validation.go
+package order
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterValidation("price_precision", validatePricePrecision)
+	}
+}
+
+// validatePricePrecision проверяет, что в цене не больше двух знаков после запятой.
+func validatePricePrecision(fl validator.FieldLevel) bool {
+	var raw string
+	switch fl.Field().Kind() {
+	case reflect.Float32:
+		raw = strconv.FormatFloat(fl.Field().Float(), 'f', -1, 32)
+	case reflect.Float64:
+		raw = strconv.FormatFloat(fl.Field().Float(), 'f', -1, 64)
+	default:
+		return false
+	}
+	if i := strings.IndexByte(raw, '.'); i >= 0 {
+		return len(raw)-i-1 <= 2
+	}
+	return true
+}
+
+// orderValidationErrors переводит ошибки валидатора в сообщения по полям.
+func orderValidationErrors(err error) map[string]string {
+	errs := make(map[string]string)
+	ve, ok := err.(validator.ValidationErrors)
+	if !ok {
+		errs["body"] = "некорректный запрос"
+		return errs
+	}
+	for _, fe := range ve {
+		switch fe.Field() {
+		case "Title":
+			if fe.Tag() == "required" {
+				errs["title"] = "название обязательно"
+			} else {
+				errs["title"] = "длина названия от 3 до 255 символов"
+			}
+		case "Description":
+			if fe.Tag() == "required" {
+				errs["description"] = "описание обязательно"
+			} else {
+				errs["description"] = "максимальная длина описания 5000 символов"
+			}
+		case "Price":
+			switch fe.Tag() {
+			case "required":
+				errs["price"] = "цена обязательна"
+			case "price_precision":
+				errs["price"] = "цена может содержать не более двух знаков после запятой"
+			default:
+				errs["price"] = "цена должна быть от 0.01 до 10000000"
+			}
+		case "Address":
+			if fe.Tag() == "required" {
+				errs["address"] = "адрес обязателен"
+			} else {
+				errs["address"] = "максимальная длина адреса 500 символов"
+			}
+		case "Longitude":
+			if fe.Tag() == "required" {
+				errs["longitude"] = "долгота обязательна"
+			} else {
+				errs["longitude"] = "долгота должна быть числом от -180 до 180"
+			}
+		case "Latitude":
+			if fe.Tag() == "required" {
+				errs["latitude"] = "широта обязательна"
+			} else {
+				errs["latitude"] = "широта должна быть числом от -90 до 90"
+			}
+		case "Status":
+			errs["status"] = "статус должен быть open, assigned, in_progress, completed или cancelled"
+		case "CategoryId":
+			if fe.Tag() == "required" {
+				errs["category_id"] = "категория обязательна"
+			} else {
+				errs["category_id"] = "неверный формат category_id"
+			}
+		case "ClientId":
+			if fe.Tag() == "required" {
+				errs["client_id"] = "клиент обязателен"
+			} else {
+				errs["client_id"] = "неверный формат client_id"
+			}
+		case "MasterId":
+			errs["master_id"] = "неверный формат master_id"
+		default:
+			errs[strings.ToLower(fe.Field())] = "неверное значение"
+		}
+	}
+	return errs
+}
@@ -0,0 +1,97 @@
+// internal/order/validation_test.go
+package order
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+func validCreateRequest() createOrderRequest {
+	return createOrderRequest{
+		Title:       "Починить кран",
+		Description: "Течёт",
+		Price:       100,
+		Address:     "Москва",
+		Longitude:   "37.6",
+		Latitude:    "55.7",
+		CategoryId:  "00000000-0000-0000-0000-0000000000aa",
+		ClientId:    testClientID,
+	}
+}
+
+func TestCreateOrderValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*createOrderRequest)
+		want   map[string]string
+	}{
+		{"valid", func(*createOrderRequest) {}, nil},
+		{"price with cents", func(r *createOrderRequest) { r.Price = 10.55 }, nil},
+		{"smallest price", func(r *createOrderRequest) { r.Price = 0.01 }, nil},
+		{"largest price", func(r *createOrderRequest) { r.Price = 10000000 }, nil},
+		{"three decimals", func(r *createOrderRequest) { r.Price = 10.555 },
+			map[string]string{"price": "цена может содержать не более двух знаков после запятой"}},
+		{"missing price", func(r *createOrderRequest) { r.Price = 0 },
+			map[string]string{"price": "цена обязательна"}},
+		{"negative price", func(r *createOrderRequest) { r.Price = -5 },
+			map[string]string{"price": "цена должна быть от 0.01 до 10000000"}},
+		{"price too high", func(r *createOrderRequest) { r.Price = 10000001 },
+			map[string]string{"price": "цена должна быть от 0.01 до 10000000"}},
+		{"latitude out of range", func(r *createOrderRequest) { r.Latitude = "90.5" },
+			map[string]string{"latitude": "широта должна быть числом от -90 до 90"}},
+		{"longitude is not a number", func(r *createOrderRequest) { r.Longitude = "восток" },
+			map[string]string{"longitude": "долгота должна быть числом от -180 до 180"}},
+		{"missing coordinates", func(r *createOrderRequest) { r.Latitude, r.Longitude = "", "" },
+			map[string]string{"latitude": "широта обязательна", "longitude": "долгота обязательна"}},
+		{"short title and bad ids", func(r *createOrderRequest) { r.Title, r.CategoryId, r.ClientId = "ab", "x", "y" },
+			map[string]string{
+				"title":       "длина названия от 3 до 255 символов",
+				"category_id": "неверный формат category_id",
+				"client_id":   "неверный формат client_id",
+			}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := validCreateRequest()
+			tt.modify(&req)
+			err := binding.Validator.ValidateStruct(&req)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if got := orderValidationErrors(err); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderStatusValidation(t *testing.T) {
+	for _, st := range allStatuses {
+		patch := patchOrderRequest{Status: &st}
+		if err := binding.Validator.ValidateStruct(&patch); err != nil {
+			t.Errorf("status %s rejected: %v", st, err)
+		}
+	}
+	bad := "done"
+	patch := patchOrderRequest{Status: &bad}
+	want := map[string]string{"status": "статус должен быть open, assigned, in_progress, completed или cancelled"}
+	if got := orderValidationErrors(binding.Validator.ValidateStruct(&patch)); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestPricePrecisionFloat64(t *testing.T) {
+	type priced struct {
+		Price float64 `binding:"price_precision"`
+	}
+	for price, ok := range map[float64]bool{1: true, 1.5: true, 19.99: true, 0.001: false, 1.234: false} {
+		if err := binding.Validator.ValidateStruct(&priced{Price: price}); (err == nil) != ok {
+			t.Errorf("price %v: err = %v, want ok=%v", price, err, ok)
+		}
+	}
+}
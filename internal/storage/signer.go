@@ -0,0 +1,56 @@
+// internal/storage/signer.go
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Signer подписывает ссылки на объекты, чтобы их можно было отдавать
+// без cookie (например, в <img>) и кэшировать до истечения срока.
+type Signer struct {
+	key     []byte
+	baseURL string
+	ttl     time.Duration
+}
+
+// NewSigner создаёт подписчик ссылок вида baseURL/<key>?expires=..&sig=..
+func NewSigner(key []byte, baseURL string, ttl time.Duration) *Signer {
+	return &Signer{key: key, baseURL: baseURL, ttl: ttl}
+}
+
+// URL возвращает подписанную ссылку на объект. Срок округляется вверх до
+// целого окна ttl, чтобы ссылка не менялась на каждый запрос и кэшировалась.
+func (s *Signer) URL(key string) string {
+	window := int64(s.ttl / time.Second)
+	exp := (time.Now().Unix()/window + 2) * window
+	q := url.Values{}
+	q.Set("expires", strconv.FormatInt(exp, 10))
+	q.Set("sig", s.sign(key, exp))
+	return s.baseURL + "/" + key + "?" + q.Encode()
+}
+
+// Verify проверяет подпись и срок действия ссылки.
+func (s *Signer) Verify(key, expires, sig string) (time.Time, bool) {
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	expiresAt := time.Unix(exp, 0)
+	if time.Now().After(expiresAt) {
+		return time.Time{}, false
+	}
+	return expiresAt, hmac.Equal([]byte(sig), []byte(s.sign(key, exp)))
+}
+
+func (s *Signer) sign(key string, exp int64) string {
+	m := hmac.New(sha256.New, s.key)
+	m.Write([]byte(key))
+	m.Write([]byte{0})
+	m.Write([]byte(strconv.FormatInt(exp, 10)))
+	return hex.EncodeToString(m.Sum(nil))
+}
@@ -0,0 +1,72 @@
+// internal/storage/signer_test.go
+package storage
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func parseURL(t *testing.T, s *Signer, raw string) (key, expires, sig string) {
+	t.Helper()
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(u.Path, s.baseURL+"/") {
+		t.Fatalf("%s is not under %s", raw, s.baseURL)
+	}
+	return strings.TrimPrefix(u.Path, s.baseURL+"/"), u.Query().Get("expires"), u.Query().Get("sig")
+}
+
+func TestSignerURL(t *testing.T) {
+	s := NewSigner([]byte("secret"), "/api/v1/media", time.Hour)
+	raw := s.URL("orders/1/photos/abc")
+	key, expires, sig := parseURL(t, s, raw)
+	if key != "orders/1/photos/abc" {
+		t.Fatalf("key %q", key)
+	}
+	expiresAt, ok := s.Verify(key, expires, sig)
+	if !ok {
+		t.Fatalf("%s does not verify", raw)
+	}
+	// срок округлён до окна и не короче ttl
+	if left := time.Until(expiresAt); left < time.Hour || left > 2*time.Hour {
+		t.Errorf("link expires in %v, want between 1h and 2h", left)
+	}
+}
+
+func TestSignerVerifyRejects(t *testing.T) {
+	s := NewSigner([]byte("secret"), "/media", time.Hour)
+	key, expires, sig := parseURL(t, s, s.URL("categories/1/icon"))
+	past := time.Now().Add(-time.Minute).Unix()
+
+	tests := []struct {
+		name, key, expires, sig string
+	}{
+		{"other key", "categories/2/icon", expires, sig},
+		{"extended expiry", key, expires + "0", sig},
+		{"wrong signature", key, expires, strings.Repeat("0", len(sig))},
+		{"expires is not a number", key, "soon", sig},
+		{"expired", key, strconv.FormatInt(past, 10), s.sign(key, past)},
+		{"signed with another secret", key, expires, NewSigner([]byte("other"), "/media", time.Hour).sign(key, mustInt(t, expires))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := s.Verify(tt.key, tt.expires, tt.sig); ok {
+				t.Fatal("link verified")
+			}
+		})
+	}
+}
+
+func mustInt(t *testing.T, s string) int64 {
+	t.Helper()
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
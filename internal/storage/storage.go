@@ -0,0 +1,27 @@
+// internal/storage/storage.go
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrNotFound — объекта с таким ключом нет.
+var ErrNotFound = errors.New("object not found")
+
+// Object — открытый для чтения объект.
+type Object struct {
+	io.ReadSeekCloser
+	Size    int64
+	ModTime time.Time
+}
+
+// Storage хранит бинарные объекты (картинки) по ключам вида "a/b/c".
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Open(ctx context.Context, key string) (*Object, error)
+	List(ctx context.Context, prefix string) ([]string, error)
+	Delete(ctx context.Context, key string) error
+}
@@ -1,11 +1,15 @@
 package user
 
 import (
+	"errors"
 	"net/http"
 
+	"github.com/Ostap00034/course-work-backend-api-gateway/internal/auth"
+	"github.com/Ostap00034/course-work-backend-api-gateway/util"
 	commonpb "github.com/Ostap00034/course-work-backend-api-specs/gen/go/common/v1"
 	userv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/user/v1"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"google.golang.org/grpc/codes"
@@ -23,15 +27,48 @@ type createUserRequest struct {
 	Role     string `json:"role" binding:"required,oneof=admin master client"`
 }
 
-// updateUserRequest — все поля опциональны
+// replaceUserRequest — полная замена профиля (PUT), все поля обязательны
+type replaceUserRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Fio   string `json:"fio" binding:"required,min=4"`
+	Role  string `json:"role" binding:"required,oneof=admin master client"`
+}
+
+// updateUserRequest — частичное изменение (PATCH, JSON Merge Patch), все поля опциональны
 type updateUserRequest struct {
 	Email *string `json:"email,omitempty" binding:"omitempty,email"`
 	Fio   *string `json:"fio,omitempty" binding:"omitempty,min=4"`
 	Role  *string `json:"role,omitempty" binding:"omitempty,oneof=admin master client"`
 }
 
+// userFields — поля профиля, которые можно передавать в маске изменений.
+var userFields = []string{"email", "fio", "role"}
+
 // Handlers
 
+// authorizeProfileChange пускает к изменению профиля только его владельца или
+// администратора. Роль меняет только администратор: свою роль пользователь
+// может передать, но не другую.
+func authorizeProfileChange(c *gin.Context, userID string, role *string) bool {
+	claims, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse("требуется авторизация", nil))
+		return false
+	}
+	if claims.Role == "admin" {
+		return true
+	}
+	if claims.UserID != userID {
+		c.JSON(http.StatusForbidden, errorResponse("можно изменять только свой профиль", nil))
+		return false
+	}
+	if role != nil && *role != claims.Role {
+		c.JSON(http.StatusForbidden, errorResponse("роль может изменить только администратор", nil))
+		return false
+	}
+	return true
+}
+
 func CreateUserHandler(client userv1.UserServiceClient) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req createUserRequest
@@ -127,6 +164,7 @@ func GetProfileHandler(client userv1.UserServiceClient) gin.HandlerFunc {
 	}
 }
 
+// ChangeProfileHandler частично изменяет профиль (PATCH, JSON Merge Patch).
 func ChangeProfileHandler(client userv1.UserServiceClient) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id := c.Param("id")
@@ -137,8 +175,18 @@ func ChangeProfileHandler(client userv1.UserServiceClient) gin.HandlerFunc {
 		}
 
 		var req updateUserRequest
-		if err := c.ShouldBindJSON(&req); err != nil {
-			errs := make(map[string]string)
+		present, err := util.ReadMergePatch(c, &req)
+		if err != nil {
+			if errors.Is(err, util.ErrUnsupportedMediaType) {
+				c.JSON(http.StatusUnsupportedMediaType, errorResponse("ожидается application/merge-patch+json", nil))
+			} else {
+				c.JSON(http.StatusBadRequest, errorResponse("ошибка валидации", map[string]string{"body": "некорректный запрос"}))
+			}
+			return
+		}
+
+		errs := make(map[string]string)
+		if err := binding.Validator.ValidateStruct(&req); err != nil {
 			if ve, ok := err.(validator.ValidationErrors); ok {
 				for _, fe := range ve {
 					switch fe.Field() {
@@ -150,26 +198,50 @@ func ChangeProfileHandler(client userv1.UserServiceClient) gin.HandlerFunc {
 						errs["role"] = "должно быть admin, master или client"
 					}
 				}
-			} else {
-				errs["body"] = "некорректный запрос"
 			}
-			c.JSON(http.StatusBadRequest, errorResponse("ошибка валидации", errs))
-			return
 		}
 
 		// Формируем protobuf-структуру с изменениями
 		userData := &commonpb.UserData{}
-		if req.Email != nil {
-			userData.Email = *req.Email
+		for k := range present {
+			switch k {
+			case "email":
+				if req.Email == nil {
+					errs["email"] = "электронную почту нельзя очистить"
+				} else {
+					userData.Email = *req.Email
+				}
+			case "fio":
+				if req.Fio == nil {
+					errs["fio"] = "фио нельзя очистить"
+				} else {
+					userData.Fio = *req.Fio
+				}
+			case "role":
+				if req.Role == nil {
+					errs["role"] = "роль нельзя очистить"
+				} else {
+					userData.Role = *req.Role
+				}
+			default:
+				errs[k] = "неизвестное поле"
+			}
+		}
+		if len(errs) > 0 {
+			c.JSON(http.StatusBadRequest, errorResponse("ошибка валидации", errs))
+			return
 		}
-		if req.Fio != nil {
-			userData.Fio = *req.Fio
+
+		mask := util.MaskFromPresent(present, userFields...)
+		if len(mask) == 0 {
+			c.JSON(http.StatusBadRequest, errorResponse("нет полей для изменения", nil))
+			return
 		}
-		if req.Role != nil {
-			userData.Role = *req.Role
+		if !authorizeProfileChange(c, userID.String(), req.Role) {
+			return
 		}
 
-		res, err := client.ChangeUser(c, &userv1.ChangeUserRequest{
+		res, err := client.ChangeUser(util.WithFieldMask(c.Request.Context(), mask...), &userv1.ChangeUserRequest{
 			UserId: userID.String(),
 			User:   userData,
 		})
@@ -196,6 +268,85 @@ func ChangeProfileHandler(client userv1.UserServiceClient) gin.HandlerFunc {
 	}
 }
 
+// ReplaceProfileHandler полностью заменяет профиль (PUT): email, fio и role
+// обязательны. Раньше PUT менял только переданные поля — клиентам, которые
+// шлют часть полей, нужно перейти на PATCH с application/merge-patch+json.
+func ReplaceProfileHandler(client userv1.UserServiceClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		userID, err := uuid.Parse(id)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse("неправильный формат id пользователя", nil))
+			return
+		}
+
+		var req replaceUserRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			errs := make(map[string]string)
+			if ve, ok := err.(validator.ValidationErrors); ok {
+				for _, fe := range ve {
+					switch fe.Field() {
+					case "Email":
+						if fe.Tag() == "required" {
+							errs["email"] = "электронная почта обязательна"
+						} else {
+							errs["email"] = "неверный формат электронной почты"
+						}
+					case "Fio":
+						if fe.Tag() == "required" {
+							errs["fio"] = "фио обязательно"
+						} else {
+							errs["fio"] = "минимальная длина фио 4 символа"
+						}
+					case "Role":
+						if fe.Tag() == "required" {
+							errs["role"] = "роль обязательна"
+						} else {
+							errs["role"] = "должно быть admin, master или client"
+						}
+					}
+				}
+			} else {
+				errs["body"] = "некорректный запрос"
+			}
+			c.JSON(http.StatusBadRequest, errorResponse("ошибка валидации", errs))
+			return
+		}
+		if !authorizeProfileChange(c, userID.String(), &req.Role) {
+			return
+		}
+
+		res, err := client.ChangeUser(util.WithFieldMask(c.Request.Context(), userFields...), &userv1.ChangeUserRequest{
+			UserId: userID.String(),
+			User: &commonpb.UserData{
+				Email: req.Email,
+				Fio:   req.Fio,
+				Role:  req.Role,
+			},
+		})
+		if err != nil {
+			if st, ok := status.FromError(err); ok {
+				switch st.Code() {
+				case codes.NotFound:
+					c.JSON(http.StatusNotFound, errorResponse(st.Message(), nil))
+				case codes.InvalidArgument:
+					c.JSON(http.StatusBadRequest, errorResponse(st.Message(), nil))
+				default:
+					c.JSON(http.StatusInternalServerError, errorResponse(st.Message(), nil))
+				}
+			} else {
+				c.JSON(http.StatusInternalServerError, errorResponse("внутренняя ошибка сервера", nil))
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, ProfileResponse{
+			Response: Response{Success: true, Message: "успешно"},
+			User:     res.User,
+		})
+	}
+}
+
 func GetUsersHandler(client userv1.UserServiceClient) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		res, err := client.GetUsers(c, &userv1.GetUsersRequest{})
@@ -217,6 +368,7 @@ func GetUsersHandler(client userv1.UserServiceClient) gin.HandlerFunc {
 func RegisterHandlers(r gin.IRouter, client userv1.UserServiceClient) {
 	r.POST("/create", CreateUserHandler(client))
 	r.GET("/profile/:id", GetProfileHandler(client))
-	r.PUT("/profile/:id", ChangeProfileHandler(client))
-	r.GET("/users", GetUsersHandler(client))
+	r.PUT("/profile/:id", ReplaceProfileHandler(client))
+	r.PATCH("/profile/:id", ChangeProfileHandler(client))
+	r.GET("/", GetUsersHandler(client))
 }
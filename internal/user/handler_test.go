@@ -0,0 +1,84 @@
+// internal/user/handler_test.go
+package user
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+
+	commonpb "github.com/Ostap00034/course-work-backend-api-specs/gen/go/common/v1"
+	userv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/user/v1"
+	"github.com/Ostap00034/course-work-backend-auth-service/util/jwt"
+)
+
+const (
+	testUserID  = "00000000-0000-0000-0000-00000000c001"
+	testOtherID = "00000000-0000-0000-0000-00000000b001"
+)
+
+// fakeUsers запоминает, был ли вызван ChangeUser.
+type fakeUsers struct {
+	userv1.UserServiceClient
+	changed bool
+}
+
+func (f *fakeUsers) ChangeUser(_ context.Context, req *userv1.ChangeUserRequest, _ ...grpc.CallOption) (*userv1.GetUserByIdResponse, error) {
+	f.changed = true
+	return &userv1.GetUserByIdResponse{User: &commonpb.UserData{Id: req.UserId, Fio: req.User.GetFio()}}, nil
+}
+
+func TestChangeProfileAccess(t *testing.T) {
+	const full = `{"email":"user@example.com","fio":"Иван Иванов","role":"client"}`
+	tests := []struct {
+		name, method, id, body string
+		userID, role           string
+		code                   int
+	}{
+		{"anonymous", "PUT", testUserID, full, "", "", http.StatusUnauthorized},
+		{"self", "PUT", testUserID, full, testUserID, "client", http.StatusOK},
+		{"self becomes admin", "PUT", testUserID, strings.Replace(full, "client", "admin", 1), testUserID, "client", http.StatusForbidden},
+		{"someone else's profile", "PUT", testUserID, full, testOtherID, "client", http.StatusForbidden},
+		{"admin changes a role", "PUT", testUserID, strings.Replace(full, "client", "master", 1), testOtherID, "admin", http.StatusOK},
+		{"partial PUT", "PUT", testUserID, `{"fio":"Иван Иванов"}`, testUserID, "client", http.StatusBadRequest},
+		{"self patches fio", "PATCH", testUserID, `{"fio":"Пётр Петров"}`, testUserID, "client", http.StatusOK},
+		{"self patches the same role", "PATCH", testUserID, `{"role":"master"}`, testUserID, "master", http.StatusOK},
+		{"self patches role", "PATCH", testUserID, `{"role":"admin"}`, testUserID, "master", http.StatusForbidden},
+		{"patch someone else", "PATCH", testUserID, `{"fio":"Пётр Петров"}`, testOtherID, "master", http.StatusForbidden},
+		{"admin patches role", "PATCH", testUserID, `{"role":"admin"}`, testOtherID, "admin", http.StatusOK},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &fakeUsers{}
+			r := gin.New()
+			RegisterHandlers(r.Group("/users"), users)
+
+			req := httptest.NewRequest(tt.method, "/users/profile/"+tt.id, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.method == "PATCH" {
+				req.Header.Set("Content-Type", "application/merge-patch+json")
+			}
+			if tt.userID != "" {
+				token, err := jwt.GenerateToken(jwt.NewClaims(tt.userID, tt.role, time.Now().Add(time.Hour)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				req.AddCookie(&http.Cookie{Name: "token", Value: token})
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.code {
+				t.Fatalf("got %d %s, want %d", w.Code, w.Body, tt.code)
+			}
+			if users.changed != (tt.code == http.StatusOK) {
+				t.Fatalf("ChangeUser called: %v", users.changed)
+			}
+		})
+	}
+}
@@ -24,10 +24,11 @@ func ClearCookie(c *gin.Context, name string) {
 	c.SetCookie(name, "", -1, "/", "", false, true)
 }
 
-// CookieToMetadata прокидывает значение cookie "auth_token" в gRPC-metadata.
+// CookieToMetadata прокидывает значение cookie "token", которое выставляет
+// логин, в gRPC-metadata.
 func CookieToMetadata() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if token, ok := GetCookie(c, "auth_token"); ok {
+		if token, ok := GetCookie(c, "token"); ok {
 			md := metadata.Pairs("authorization", token)
 			c.Request = c.Request.WithContext(metadata.NewOutgoingContext(c.Request.Context(), md))
 		}
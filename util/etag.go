@@ -0,0 +1,77 @@
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/proto"
+)
+
+// ETag считает сильный ETag ресурса по хэшу его содержимого.
+// Сервисы не отдают номер версии, поэтому версией считается сам ресурс.
+// extra — данные ресурса, которые шлюз хранит сам и которых нет в m.
+func ETag(m proto.Message, extra ...string) string {
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(m)
+	if err != nil {
+		return ""
+	}
+	h := sha256.New()
+	h.Write(b)
+	for _, e := range extra {
+		h.Write([]byte{0})
+		h.Write([]byte(e))
+	}
+	sum := h.Sum(nil)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// SetETag выставляет заголовок ETag, если он не пустой.
+func SetETag(c *gin.Context, etag string) {
+	if etag != "" {
+		c.Header("ETag", etag)
+	}
+}
+
+// NotModified сообщает, совпадает ли If-None-Match с текущим ETag
+// (тогда клиенту нужно ответить 304).
+func NotModified(c *gin.Context, etag string) bool {
+	h := c.GetHeader("If-None-Match")
+	return h != "" && etagListMatches(h, etag, true)
+}
+
+// HasIfMatch сообщает, передал ли клиент заголовок If-Match.
+func HasIfMatch(c *gin.Context) bool {
+	return c.GetHeader("If-Match") != ""
+}
+
+// IfMatch сообщает, удовлетворяет ли текущий ETag заголовку If-Match.
+// Без заголовка условие считается выполненным.
+func IfMatch(c *gin.Context, etag string) bool {
+	h := c.GetHeader("If-Match")
+	return h == "" || etagListMatches(h, etag, false)
+}
+
+// etagListMatches сравнивает ETag со списком из заголовка. При weak=true
+// префикс W/ игнорируется (слабое сравнение, RFC 9110 §8.8.3.2).
+func etagListMatches(header, etag string, weak bool) bool {
+	if etag == "" {
+		return false
+	}
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		} else if strings.HasPrefix(candidate, "W/") {
+			continue
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package util
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	commonpbv1 "github.com/Ostap00034/course-work-backend-api-specs/gen/go/common/v1"
+)
+
+func TestETag(t *testing.T) {
+	order := &commonpbv1.OrderData{Id: "1", Title: "Кран", Price: 100}
+	etag := ETag(order)
+	if len(etag) != 34 || etag[0] != '"' || etag[33] != '"' {
+		t.Fatalf("ETag %s is not a quoted 32-digit hash", etag)
+	}
+	if again := ETag(&commonpbv1.OrderData{Id: "1", Title: "Кран", Price: 100}); again != etag {
+		t.Errorf("equal messages: %s and %s", etag, again)
+	}
+	if other := ETag(&commonpbv1.OrderData{Id: "1", Title: "Кран", Price: 101}); other == etag {
+		t.Error("changed field keeps the ETag")
+	}
+	// extra входит в хэш и не склеивается с соседями
+	if ETag(order, "parent") == etag {
+		t.Error("extra is ignored")
+	}
+	if ETag(order, "ab", "c") == ETag(order, "a", "bc") {
+		t.Error("extras are concatenated without a separator")
+	}
+}
+
+func conditional(header, value string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/", nil)
+	if header != "" {
+		c.Request.Header.Set(header, value)
+	}
+	return c
+}
+
+func TestConditionalHeaders(t *testing.T) {
+	const etag = `"abc"`
+	tests := []struct {
+		name, header, value string
+		notModified, match  bool
+	}{
+		{"no header", "", "", false, true},
+		{"If-None-Match equal", "If-None-Match", `"abc"`, true, true},
+		{"If-None-Match weak", "If-None-Match", `W/"abc"`, true, true},
+		{"If-None-Match in a list", "If-None-Match", `"x", "abc"`, true, true},
+		{"If-None-Match other", "If-None-Match", `"x"`, false, true},
+		{"If-None-Match any", "If-None-Match", `*`, true, true},
+		{"If-Match equal", "If-Match", `"abc"`, false, true},
+		{"If-Match in a list", "If-Match", `"x","abc"`, false, true},
+		{"If-Match weak never matches", "If-Match", `W/"abc"`, false, false},
+		{"If-Match other", "If-Match", `"x"`, false, false},
+		{"If-Match any", "If-Match", `*`, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := conditional(tt.header, tt.value)
+			if got := NotModified(c, etag); got != tt.notModified {
+				t.Errorf("NotModified = %v, want %v", got, tt.notModified)
+			}
+			if got := IfMatch(c, etag); got != tt.match {
+				t.Errorf("IfMatch = %v, want %v", got, tt.match)
+			}
+			if got := HasIfMatch(c); got != (tt.header == "If-Match") {
+				t.Errorf("HasIfMatch = %v", got)
+			}
+		})
+	}
+	// без ETag ресурса условие If-Match не выполняется даже для *
+	if IfMatch(conditional("If-Match", "*"), "") {
+		t.Error("If-Match: * matched a resource without an ETag")
+	}
+}
+
+func TestSetETag(t *testing.T) {
+	c := conditional("", "")
+	SetETag(c, "")
+	if _, ok := c.Writer.Header()["Etag"]; ok {
+		t.Fatal("empty ETag was set")
+	}
+	SetETag(c, `"abc"`)
+	if got := c.Writer.Header().Get("ETag"); got != `"abc"` {
+		t.Fatalf("ETag %q", got)
+	}
+}
@@ -13,13 +13,13 @@ func ParseUUIDParam(c *gin.Context, name string) (uuid.UUID, bool) {
 	raw := c.Param(name)
 	id, err := uuid.Parse(raw)
 	if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-					"success": false,
-					"message": "invalid id format",
-					"errors": map[string]string{name: "must be a valid UUID"},
-			})
-			c.Abort()
-			return uuid.Nil, false
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "invalid id format",
+			"errors":  map[string]string{name: "must be a valid UUID"},
+		})
+		c.Abort()
+		return uuid.Nil, false
 	}
 	return id, true
 }
@@ -0,0 +1,79 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/metadata"
+)
+
+// FieldMaskMetadataKey — ключ gRPC-metadata, в котором сервисам передаётся
+// список изменяемых полей. Значение — имена полей через запятую.
+const FieldMaskMetadataKey = "x-field-mask"
+
+var (
+	// ErrUnsupportedMediaType — тело PATCH не в формате JSON Merge Patch.
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+	// ErrNotObject — патч не является JSON-объектом.
+	ErrNotObject = errors.New("merge patch must be a JSON object")
+)
+
+// ReadMergePatch читает тело запроса как JSON Merge Patch (RFC 7386):
+// раскладывает его в dst и возвращает множество ключей верхнего уровня,
+// которые присутствовали в патче. Ключ со значением null присутствует,
+// но соответствующее поле-указатель в dst остаётся nil — это означает
+// «очистить поле».
+func ReadMergePatch(c *gin.Context, dst any) (map[string]bool, error) {
+	if ct := c.GetHeader("Content-Type"); ct != "" {
+		mt, _, err := mime.ParseMediaType(ct)
+		if err != nil || (mt != "application/merge-patch+json" && mt != "application/json") {
+			return nil, ErrUnsupportedMediaType
+		}
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, ErrNotObject
+	}
+	if err := json.Unmarshal(body, dst); err != nil {
+		return nil, err
+	}
+
+	present := make(map[string]bool, len(raw))
+	for k := range raw {
+		present[k] = true
+	}
+	return present, nil
+}
+
+// WithFieldMask добавляет в исходящую gRPC-metadata маску изменяемых полей.
+func WithFieldMask(ctx context.Context, paths ...string) context.Context {
+	sorted := append([]string(nil), paths...)
+	sort.Strings(sorted)
+	return metadata.AppendToOutgoingContext(ctx, FieldMaskMetadataKey, strings.Join(sorted, ","))
+}
+
+// MaskFromPresent возвращает ключи патча, входящие в allowed, в виде маски полей.
+func MaskFromPresent(present map[string]bool, allowed ...string) []string {
+	mask := make([]string, 0, len(present))
+	for _, k := range allowed {
+		if present[k] {
+			mask = append(mask, k)
+		}
+	}
+	return mask
+}
@@ -0,0 +1,86 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/metadata"
+)
+
+type patchTarget struct {
+	Title *string  `json:"title"`
+	Price *float32 `json:"price"`
+}
+
+func patchContext(contentType, body string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("PATCH", "/", strings.NewReader(body))
+	if contentType != "" {
+		c.Request.Header.Set("Content-Type", contentType)
+	}
+	return c
+}
+
+func TestReadMergePatch(t *testing.T) {
+	var dst patchTarget
+	present, err := ReadMergePatch(patchContext("application/merge-patch+json; charset=utf-8",
+		`{"title":null,"price":0,"extra":1}`), &dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(present, map[string]bool{"title": true, "price": true, "extra": true}) {
+		t.Errorf("present = %v", present)
+	}
+	// null очищает поле, а 0 — это значение, а не отсутствие поля
+	if dst.Title != nil || dst.Price == nil || *dst.Price != 0 {
+		t.Errorf("dst = %+v", dst)
+	}
+
+	for _, tt := range []struct {
+		name, contentType, body string
+		want                    error
+	}{
+		{"plain json is accepted", "application/json", `{}`, nil},
+		{"no content type", "", `{"title":"x"}`, nil},
+		{"form", "application/x-www-form-urlencoded", `title=x`, ErrUnsupportedMediaType},
+		{"json patch", "application/json-patch+json", `[]`, ErrUnsupportedMediaType},
+		{"null", "application/merge-patch+json", `null`, ErrNotObject},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadMergePatch(patchContext(tt.contentType, tt.body), &patchTarget{})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+	for _, body := range []string{`[]`, `"x"`, `{"title":`, `{"title":1}`} {
+		if _, err := ReadMergePatch(patchContext("application/merge-patch+json", body), &patchTarget{}); err == nil {
+			t.Errorf("%s accepted", body)
+		}
+	}
+}
+
+func TestFieldMask(t *testing.T) {
+	present := map[string]bool{"title": true, "status": true, "unknown": true}
+	mask := MaskFromPresent(present, "price", "status", "title")
+	if !reflect.DeepEqual(mask, []string{"status", "title"}) {
+		t.Fatalf("mask = %v", mask)
+	}
+
+	ctx := WithFieldMask(context.Background(), "title", "status")
+	md, _ := metadata.FromOutgoingContext(ctx)
+	if got := md.Get(FieldMaskMetadataKey); !reflect.DeepEqual(got, []string{"status,title"}) {
+		t.Fatalf("metadata %s = %v", FieldMaskMetadataKey, got)
+	}
+	// маска добавляется к уже исходящей metadata, не затирая её
+	ctx = WithFieldMask(metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", "t")), "status")
+	md, _ = metadata.FromOutgoingContext(ctx)
+	if len(md.Get("authorization")) != 1 || len(md.Get(FieldMaskMetadataKey)) != 1 {
+		t.Fatalf("metadata = %v", md)
+	}
+}